@@ -37,6 +37,18 @@ func assertJSONEqual(t T, a, b []byte) {
 	}
 }
 
+// jsonEqual returns true if two JSON representations are the same.
+func jsonEqual(a, b []byte) bool {
+	var ja, jb interface{}
+	if err := json.Unmarshal(a, &ja); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, &jb); err != nil {
+		return false
+	}
+	return objectsAreEqual(ja, jb)
+}
+
 func objectsAreEqual(expected, actual interface{}) bool {
 	if expected == nil || actual == nil {
 		return expected == actual
@@ -0,0 +1,104 @@
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Capture expression prefixes.
+const (
+	// capHeader is a prefix of expressions capturing response header value.
+	capHeader = "header:"
+
+	// capJSON is a prefix of expressions capturing value from JSON body.
+	capJSON = "$"
+)
+
+// ErrNoCapture represents an error when value cannot be captured from
+// the response.
+var ErrNoCapture = errors.New("cannot capture value")
+
+// capture returns value described by the capture expression from HTTP
+// response headers or body.
+//
+// Supported expressions:
+//
+//   header:ETag         - the first value of ETag response header,
+//   $.id                - the id field of JSON response body,
+//   $.items[0].name     - the name field of the first items element.
+//
+func capture(expr string, hs http.Header, body []byte) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(expr, capHeader):
+		key := strings.TrimSpace(strings.TrimPrefix(expr, capHeader))
+		vv := hs.Values(key)
+		if len(vv) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoCapture, expr)
+		}
+		return vv[0], nil
+
+	case strings.HasPrefix(expr, capJSON):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrNoCapture, expr, err)
+		}
+		val, ok := jsonPath(v, strings.TrimPrefix(expr, capJSON))
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNoCapture, expr)
+		}
+		return val, nil
+
+	default:
+		return nil, fmt.Errorf("%w: invalid expression %s", ErrNoCapture, expr)
+	}
+}
+
+// jsonPath returns value pointed by simple JSON path (e.g. .items[0].id)
+// from v decoded from JSON.
+func jsonPath(v interface{}, pth string) (interface{}, bool) {
+	for pth != "" {
+		switch pth[0] {
+		case '.':
+			pth = pth[1:]
+			end := strings.IndexAny(pth, ".[")
+			if end == -1 {
+				end = len(pth)
+			}
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = obj[pth[:end]]; !ok {
+				return nil, false
+			}
+			pth = pth[end:]
+
+		case '[':
+			end := strings.IndexByte(pth, ']')
+			if end == -1 {
+				return nil, false
+			}
+			idx, err := strconv.Atoi(pth[1:end])
+			if err != nil {
+				return nil, false
+			}
+			arr, ok := v.([]interface{})
+			if !ok || idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			v = arr[idx]
+			pth = pth[end+1:]
+
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,79 @@
+package golden
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_capture(t *testing.T) {
+	// --- Given ---
+	hs := http.Header{}
+	hs.Add("ETag", `"abc"`)
+	body := []byte(`{"id": 123, "name": "n", "items": [{"id": "i0"}, {"id": "i1"}]}`)
+
+	tt := []struct {
+		testN string
+
+		expr string
+		exp  interface{}
+	}{
+		{"header", "header:ETag", `"abc"`},
+		{"header with space", "header: ETag", `"abc"`},
+		{"json number", "$.id", json.Number("123")},
+		{"json string", "$.name", "n"},
+		{"json array", "$.items[1].id", "i1"},
+		{"json root", "$", map[string]interface{}{
+			"id":   json.Number("123"),
+			"name": "n",
+			"items": []interface{}{
+				map[string]interface{}{"id": "i0"},
+				map[string]interface{}{"id": "i1"},
+			},
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- When ---
+			got, err := capture(tc.expr, hs, body)
+
+			// --- Then ---
+			require.NoError(t, err)
+			assert.Exactly(t, tc.exp, got)
+		})
+	}
+}
+
+func Test_capture_errors(t *testing.T) {
+	// --- Given ---
+	body := []byte(`{"id": 123, "items": [{"id": "i0"}]}`)
+
+	tt := []struct {
+		testN string
+
+		expr string
+	}{
+		{"missing header", "header:ETag"},
+		{"missing field", "$.name"},
+		{"index out of range", "$.items[1].id"},
+		{"not an array", "$.id[0]"},
+		{"not an object", "$.id.val"},
+		{"invalid expression", "id"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- When ---
+			got, err := capture(tc.expr, http.Header{}, body)
+
+			// --- Then ---
+			assert.True(t, errors.Is(err, ErrNoCapture))
+			assert.Nil(t, got)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package golden
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// eachCfg represents Each configuration.
+type eachCfg struct {
+	parallel bool        // Run subtests in parallel.
+	data     interface{} // Golden file template data.
+	opts     []tplOpt    // Golden file template options.
+}
+
+// eachOpt represents Each option.
+type eachOpt func(cfg *eachCfg)
+
+// EachParallel makes Each run subtests in parallel.
+func EachParallel() eachOpt {
+	return func(cfg *eachCfg) {
+		cfg.parallel = true
+	}
+}
+
+// EachData sets template data and template options golden files are opened
+// with. See Open for details.
+func EachData(data interface{}, opts ...tplOpt) eachOpt {
+	return func(cfg *eachCfg) {
+		cfg.data = data
+		cfg.opts = opts
+	}
+}
+
+// Each opens every exchange golden file matching the pattern (see
+// filepath.Glob) and calls fn with it in a named subtest. The subtest name
+// is the value of the name key in the exchange metadata or the golden file
+// name without extension:
+//
+//   golden.Each(t, "testdata/api/*.yaml", func(t *testing.T, ex *golden.Exchange) {
+//       ex.AssertHandler(handler)
+//   })
+//
+// The test fails when no golden files match the pattern.
+func Each(t *testing.T, pattern string, fn func(t *testing.T, ex *Exchange), opts ...eachOpt) {
+	t.Helper()
+
+	cfg := &eachCfg{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	pths, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+	if len(pths) == 0 {
+		t.Fatalf("no golden files match %s", pattern)
+		return
+	}
+
+	for _, pth := range pths {
+		pth := pth
+		ex := NewExchange(Open(t, pth, cfg.data, cfg.opts...))
+		if ex == nil {
+			return
+		}
+
+		t.Run(eachName(pth, ex), func(t *testing.T) {
+			if cfg.parallel {
+				t.Parallel()
+			}
+			ex.setup(t)
+			fn(t, ex)
+		})
+	}
+}
+
+// eachName returns subtest name for exchange loaded from golden file pth.
+func eachName(pth string, ex *Exchange) string {
+	if name, ok := ex.Meta["name"].(string); ok && name != "" {
+		return name
+	}
+	base := filepath.Base(pth)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
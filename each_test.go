@@ -0,0 +1,76 @@
+package golden
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Each(t *testing.T) {
+	// --- Given ---
+	var names []string
+
+	// --- When ---
+	Each(t, "testdata/each/*.yaml", func(t *testing.T, ex *Exchange) {
+		names = append(names, t.Name())
+		ex.AssertHandler(successHandler())
+	}, EachData(Map{"kind": "item"}))
+
+	// --- Then ---
+	exp := []string{
+		"Test_Each/get_success",
+		"Test_Each/create_item",
+	}
+	assert.Exactly(t, exp, names)
+}
+
+func Test_Each_EachParallel(t *testing.T) {
+	// --- Given ---
+	var mx sync.Mutex
+	var names []string
+
+	// --- When ---
+	t.Run("group", func(t *testing.T) {
+		Each(t, "testdata/each/*.yaml", func(t *testing.T, ex *Exchange) {
+			mx.Lock()
+			names = append(names, t.Name())
+			mx.Unlock()
+			ex.AssertHandler(successHandler())
+		}, EachParallel(), EachData(Map{"kind": "item"}))
+	})
+
+	// --- Then ---
+	sort.Strings(names)
+	exp := []string{
+		"Test_Each_EachParallel/group/create_item",
+		"Test_Each_EachParallel/group/get_success",
+	}
+	assert.Exactly(t, exp, names)
+}
+
+func Test_eachName(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		pth  string
+		meta map[string]interface{}
+		exp  string
+	}{
+		{"file name", "testdata/api/get_user.yaml", nil, "get_user"},
+		{"meta name", "testdata/api/get_user.yaml", map[string]interface{}{"name": "get user"}, "get user"},
+		{"empty meta name", "testdata/api/get_user.yaml", map[string]interface{}{"name": ""}, "get_user"},
+		{"no extension", "testdata/api/get_user", nil, "get_user"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- When ---
+			got := eachName(tc.pth, &Exchange{Meta: tc.meta})
+
+			// --- Then ---
+			assert.Exactly(t, tc.exp, got)
+		})
+	}
+}
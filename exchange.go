@@ -1,22 +1,48 @@
 package golden
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Exchange represents HTTP request / response exchange.
+//
+// Instead of a single request / response pair the exchange may describe
+// ordered list of steps, each being request / response exchange, which are
+// executed sequentially by Assert and AssertHandler methods. Values captured
+// from step responses (see Response.Captured) replace ${name} references
+// in path, query, headers and body of requests of subsequent steps.
+// References to values which were not captured are left unchanged.
+//
+// Step failure messages start with the step number, request method and
+// path, e.g. "step 2 GET /items/${itemId}: ...".
+//
+// Captured values use a different syntax than templates, so the golden file
+// may be opened with template data (see Open) and use captured values at
+// the same time.
 type Exchange struct {
+	// Exchange metadata.
+	Meta map[string]interface{} `yaml:"meta,omitempty"`
+
 	// HTTP request.
-	Request *Request `yaml:"request"`
+	Request *Request `yaml:"request,omitempty"`
 
 	// HTTP response.
-	Response *Response `yaml:"response"`
+	Response *Response `yaml:"response,omitempty"`
+
+	// Exchange steps.
+	Steps []*Exchange `yaml:"steps,omitempty"`
 
 	// Test manager.
 	t T
@@ -37,8 +63,20 @@ func NewExchange(t T, r io.Reader) *Exchange {
 		t.Fatal(err)
 		return nil
 	}
+	ex.setup(t)
+
+	return ex
+}
+
+// setup sets test manager and validates exchange loaded from golden file.
+func (ex *Exchange) setup(t T) {
 	ex.t = t
 
+	if len(ex.Steps) > 0 && (ex.Request != nil || ex.Response != nil) {
+		t.Fatal(errors.New("exchange with steps cannot have request or response"))
+		return
+	}
+
 	if ex.Request != nil {
 		ex.Request.t = t
 		ex.Request.validate()
@@ -49,13 +87,286 @@ func NewExchange(t T, r io.Reader) *Exchange {
 		ex.Response.validate()
 	}
 
-	return ex
+	for i, step := range ex.Steps {
+		if step == nil || step.Request == nil || step.Response == nil {
+			t.Fatal(errors.New("exchange step needs request and response"))
+			return
+		}
+		if len(step.Steps) > 0 {
+			t.Fatal(errors.New("exchange step cannot have steps"))
+			return
+		}
+		step.setup(&stepT{T: t, n: i + 1, req: step.Request})
+	}
+}
+
+// stepT is a test manager prefixing failure messages with the exchange step
+// number, request method and path.
+type stepT struct {
+	T
+
+	n   int      // Step number starting from 1.
+	req *Request // Step request.
+}
+
+// prefix returns failure message prefix.
+func (st *stepT) prefix() string {
+	return fmt.Sprintf("step %d %s %s: ", st.n, st.req.Method, st.req.Path)
+}
+
+// Fatal prefixes the failure message and calls Fatal.
+func (st *stepT) Fatal(args ...interface{}) {
+	st.T.Helper()
+	st.T.Fatal(st.prefix() + fmt.Sprint(args...))
+}
+
+// Fatalf prefixes the failure message and calls Fatalf.
+func (st *stepT) Fatalf(format string, args ...interface{}) {
+	st.T.Helper()
+	st.T.Fatalf(strings.ReplaceAll(st.prefix(), "%", "%%")+format, args...)
+}
+
+// ExchangeFrom returns new instance of HTTP request / response Exchange
+// representing req and rsp. See RequestFrom and ResponseFrom for details.
+func ExchangeFrom(t T, req *http.Request, rsp *http.Response) *Exchange {
+	t.Helper()
+
+	return &Exchange{
+		Request:  RequestFrom(t, req),
+		Response: ResponseFrom(t, rsp),
+		t:        t,
+	}
+}
+
+// exCfg represents exchange configuration.
+type exCfg struct {
+	ctx context.Context   // Request context.
+	cli *http.Client      // HTTP client used to make requests.
+	rt  http.RoundTripper // HTTP transport used by the client.
+
+	timeout  time.Duration // Polling timeout.
+	interval time.Duration // Polling interval.
+	attempts int           // Maximum number of polling attempts.
+}
+
+// DefaultPollInterval is the default interval between polling attempts.
+const DefaultPollInterval = 100 * time.Millisecond
+
+// newExCfg returns exchange configuration with applied options.
+func newExCfg(opts ...exOpt) *exCfg {
+	cfg := &exCfg{
+		ctx:      context.Background(),
+		interval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// retry returns true if the n-th request may be followed by another one.
+func (cfg *exCfg) retry(n int, start time.Time) bool {
+	if cfg.timeout == 0 && cfg.attempts == 0 {
+		return false
+	}
+	if cfg.attempts > 0 && n >= cfg.attempts {
+		return false
+	}
+	if cfg.timeout > 0 && time.Since(start)+cfg.interval > cfg.timeout {
+		return false
+	}
+	return cfg.ctx.Err() == nil
+}
+
+// client returns HTTP client configured with exchange options.
+func (cfg *exCfg) client() *http.Client {
+	cli := cfg.cli
+	if cli == nil {
+		cli = &http.Client{}
+	}
+	if cfg.rt != nil {
+		c := *cli
+		c.Transport = cfg.rt
+		cli = &c
+	}
+	return cli
+}
+
+// exOpt represents exchange option.
+type exOpt func(cfg *exCfg)
+
+// ExContext sets the context of the request made by the exchange. It can be
+// used to set request deadline, cancellation or to pass request scoped values.
+func ExContext(ctx context.Context) exOpt {
+	return func(cfg *exCfg) {
+		cfg.ctx = ctx
+	}
+}
+
+// ExPoll makes Exchange.Assert and Exchange.AssertHandler repeat the request
+// every interval until the response matches the golden file or the timeout
+// expires. The last received response is asserted, so the test fails with
+// the last mismatch.
+func ExPoll(timeout, interval time.Duration) exOpt {
+	return func(cfg *exCfg) {
+		cfg.timeout = timeout
+		cfg.interval = interval
+	}
+}
+
+// ExAttempts makes Exchange.Assert and Exchange.AssertHandler repeat the
+// request until the response matches the golden file but no more than n
+// times. It may be used together with ExPoll to limit both the time and
+// number of requests. When used alone requests are repeated every
+// DefaultPollInterval.
+func ExAttempts(n int) exOpt {
+	return func(cfg *exCfg) {
+		cfg.attempts = n
+	}
+}
+
+// ExClient sets HTTP client used by Exchange.Assert to make the request.
+// It can be used to pass a client with timeouts, cookie jar, proxy or TLS
+// configuration for example the one returned by httptest.Server.Client().
+func ExClient(cli *http.Client) exOpt {
+	return func(cfg *exCfg) {
+		cfg.cli = cli
+	}
+}
+
+// ExTransport sets HTTP transport used by the HTTP client Exchange.Assert
+// uses to make the request. The client passed with ExClient is not modified.
+func ExTransport(rt http.RoundTripper) exOpt {
+	return func(cfg *exCfg) {
+		cfg.rt = rt
+	}
 }
 
 // Assert makes the request described in the golden file to host and asserts
 // the response matches. It returns constructed request and received response
-// in case further assertions need to be done.
-func (ex *Exchange) Assert(host string) (*http.Request, *http.Response) {
+// in case further assertions need to be done. For exchanges with steps, the
+// steps are executed in order and the last request and response is returned.
+//
+// By default, the request is made with zero value http.Client, use ExClient
+// and ExTransport options to customize it:
+//
+//   ex.Assert(srv.Listener.Addr().String(), ExClient(srv.Client()))
+//
+func (ex *Exchange) Assert(host string, opts ...exOpt) (*http.Request, *http.Response) {
+	ex.t.Helper()
+
+	cfg := newExCfg(opts...)
+
+	var req *http.Request
+	var rsp *http.Response
+	data := make(Map)
+	for _, step := range ex.steps() {
+		step = step.withData(data)
+		send := func() (*http.Request, *http.Response, time.Duration) {
+			return step.do(host, cfg)
+		}
+		if req, rsp = step.poll(cfg, send); req == nil {
+			return nil, nil
+		}
+		data.Merge(step.Response.Captured())
+	}
+	return req, rsp
+}
+
+// AssertHandler serves the request described in the golden file with h and
+// asserts the response matches. It returns constructed request and received
+// response in case further assertions need to be done. For exchanges with
+// steps, the steps are executed in order and the last request and response
+// is returned.
+//
+// Only ExContext, ExPoll and ExAttempts options are used, ExClient and
+// ExTransport options are ignored.
+func (ex *Exchange) AssertHandler(h http.Handler, opts ...exOpt) (*http.Request, *http.Response) {
+	ex.t.Helper()
+
+	cfg := newExCfg(opts...)
+
+	var req *http.Request
+	var rsp *http.Response
+	data := make(Map)
+	for _, step := range ex.steps() {
+		step = step.withData(data)
+		send := func() (*http.Request, *http.Response, time.Duration) {
+			return step.serve(h, cfg)
+		}
+		req, rsp = step.poll(cfg, send)
+		data.Merge(step.Response.Captured())
+	}
+	return req, rsp
+}
+
+// Captured returns values captured from responses asserted by the last
+// call to Assert or AssertHandler. For exchanges with steps values captured
+// from all steps are returned. See Response.Captured for details.
+func (ex *Exchange) Captured() Map {
+	data := make(Map)
+	for _, step := range ex.steps() {
+		if step.Response != nil {
+			data.Merge(step.Response.Captured())
+		}
+	}
+	return data
+}
+
+// withData returns the exchange with references to captured values in the
+// request replaced with values from data. The exchange is returned
+// unchanged when data is empty.
+func (ex *Exchange) withData(data Map) *Exchange {
+	if len(data) == 0 {
+		return ex
+	}
+	return &Exchange{
+		Request:  ex.Request.render(data),
+		Response: ex.Response,
+		t:        ex.t,
+	}
+}
+
+// poll calls send until the response matches the golden file or polling
+// budget is exhausted and asserts the last response and its duration.
+// It returns nils if send returns nils.
+func (ex *Exchange) poll(cfg *exCfg, send func() (*http.Request, *http.Response, time.Duration)) (*http.Request, *http.Response) {
+	ex.t.Helper()
+
+	start := time.Now()
+	for n := 1; ; n++ {
+		req, rsp, d := send()
+		if req == nil {
+			return nil, nil
+		}
+
+		if !cfg.retry(n, start) || ex.Response.check(rsp) == nil {
+			ex.Response.Assert(rsp)
+			ex.Response.AssertDuration(d)
+			return req, rsp
+		}
+
+		select {
+		case <-cfg.ctx.Done():
+		case <-time.After(cfg.interval):
+		}
+	}
+}
+
+// steps returns exchange steps. Exchange without steps is its only step.
+func (ex *Exchange) steps() []*Exchange {
+	if len(ex.Steps) > 0 {
+		return ex.Steps
+	}
+	return []*Exchange{ex}
+}
+
+// do makes the request described in the golden file to host and returns
+// constructed request, received response and the time it took to make the
+// request and read the response body. It returns nils on error.
+func (ex *Exchange) do(host string, cfg *exCfg) (*http.Request, *http.Response, time.Duration) {
+	ex.t.Helper()
+
 	u := url.URL{
 		Scheme:   ex.Request.Scheme,
 		Host:     host,
@@ -63,22 +374,46 @@ func (ex *Exchange) Assert(host string) (*http.Request, *http.Response) {
 		RawQuery: ex.Request.Query,
 	}
 
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		cfg.ctx,
 		ex.Request.Method,
 		u.String(),
 		strings.NewReader(ex.Request.Body),
 	)
 	if err != nil {
 		ex.t.Fatal(err)
+		return nil, nil, 0
 	}
 	req.Header = lines2Headers(ex.t, ex.Request.Headers...)
-	cli := &http.Client{}
-	rsp, err := cli.Do(req)
+
+	start := time.Now()
+	rsp, err := cfg.client().Do(req)
 	if err != nil {
 		ex.t.Fatal(err)
+		return nil, nil, 0
 	}
-	ex.Response.Assert(rsp)
-	return req, rsp
+	body, err := ioutil.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+	if err != nil {
+		ex.t.Fatal(err)
+		return nil, nil, 0
+	}
+	d := time.Since(start)
+	rsp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return req, rsp, d
+}
+
+// serve serves the request described in the golden file with h and returns
+// constructed request, received response and the time it took to serve it.
+func (ex *Exchange) serve(h http.Handler, cfg *exCfg) (*http.Request, *http.Response, time.Duration) {
+	ex.t.Helper()
+
+	req := ex.Request.RequestWithContext(cfg.ctx)
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(rec, req)
+	return req, rec.Result(), time.Since(start)
 }
 
 // WriteTo writes golden file to w.
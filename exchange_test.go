@@ -2,12 +2,21 @@ package golden
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	. "github.com/rzajac/golden/internal"
 )
 
 func Test_Exchange_request_response(t *testing.T) {
@@ -114,3 +123,475 @@ func Test_Exchange_WriteTo(t *testing.T) {
 	assert.Exactly(t, exp, got.Response.Headers)
 	assert.Exactly(t, "{ \"success\": true }\n", got.Response.Body)
 }
+
+// successHandler returns HTTP handler responding with JSON success body.
+func successHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	})
+}
+
+func Test_Exchange_Assert_ExClient(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewTLSServer(successHandler())
+	defer srv.Close()
+
+	gld := NewExchange(Open(t, "testdata/exchange_tls.yaml", nil))
+
+	// --- When ---
+	req, rsp := gld.Assert(srv.Listener.Addr().String(), ExClient(srv.Client()))
+
+	// --- Then ---
+	assert.Exactly(t, "https", req.URL.Scheme)
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+}
+
+func Test_Exchange_Assert_ExTransport(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewTLSServer(successHandler())
+	defer srv.Close()
+
+	gld := NewExchange(Open(t, "testdata/exchange_tls.yaml", nil))
+	cli := &http.Client{}
+
+	// --- When ---
+	_, rsp := gld.Assert(
+		srv.Listener.Addr().String(),
+		ExClient(cli),
+		ExTransport(srv.Client().Transport),
+	)
+
+	// --- Then ---
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+	assert.Nil(t, cli.Transport)
+}
+
+func Test_Exchange_AssertHandler(t *testing.T) {
+	// --- Given ---
+	gld := NewExchange(Open(t, "testdata/exchange.yaml", nil))
+
+	// --- When ---
+	req, rsp := gld.AssertHandler(successHandler())
+
+	// --- Then ---
+	assert.Exactly(t, "/some/path", req.URL.Path)
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+}
+
+func Test_Exchange_AssertHandler_ResponseDoesNotMatch(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatalf", "expected response status code %d got %d", 200, 404)
+
+	gld := NewExchange(Open(mck, "testdata/exchange.yaml", nil))
+
+	// --- When ---
+	gld.AssertHandler(http.NotFoundHandler())
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Exchange_Assert_ExContext(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(500 * time.Millisecond):
+			}
+		}),
+	)
+	defer srv.Close()
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		return errors.Is(err, context.DeadlineExceeded)
+	}))
+
+	gld := NewExchange(Open(mck, "testdata/exchange_tls.yaml", nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// --- When ---
+	req, rsp := gld.Assert(
+		srv.Listener.Addr().String(),
+		ExClient(srv.Client()),
+		ExContext(ctx),
+	)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+	assert.Nil(t, req)
+	assert.Nil(t, rsp)
+}
+
+func Test_Exchange_AssertHandler_ExContext(t *testing.T) {
+	// --- Given ---
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "val")
+	gld := NewExchange(Open(t, "testdata/exchange.yaml", nil))
+
+	// --- When ---
+	req, _ := gld.AssertHandler(successHandler(), ExContext(ctx))
+
+	// --- Then ---
+	assert.Exactly(t, "val", req.Context().Value(key{}))
+}
+
+func Test_ExchangeFrom(t *testing.T) {
+	// --- Given ---
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/some/path?key0=val0",
+		strings.NewReader("body"),
+	)
+	req.Header.Add("Content-Type", "text/plain")
+
+	rec := httptest.NewRecorder()
+	successHandler().ServeHTTP(rec, req)
+	rsp := rec.Result()
+
+	// --- When ---
+	gld := ExchangeFrom(t, req, rsp)
+
+	// --- Then ---
+	dst := &bytes.Buffer{}
+	_, err := gld.WriteTo(dst)
+	require.NoError(t, err)
+
+	got := NewExchange(t, dst)
+	assert.Exactly(t, http.MethodPost, got.Request.Method)
+	assert.Exactly(t, "/some/path", got.Request.Path)
+	assert.Exactly(t, "key0=val0", got.Request.Query)
+	assert.Exactly(t, TypeText, got.Request.BodyType)
+	assert.Exactly(t, "body", got.Request.Body)
+	assert.Exactly(t, 200, got.Response.StatusCode)
+	assert.Exactly(t, TypeJSON, got.Response.BodyType)
+	assert.Exactly(t, `{"success":true}`, got.Response.Body)
+
+	got.AssertHandler(successHandler())
+}
+
+// itemsHandler returns HTTP handler implementing simple items CRUD API.
+func itemsHandler(idPrefix string) http.Handler {
+	var cnt int
+	items := make(map[string]map[string]string)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/items/")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/items":
+			item := make(map[string]string)
+			if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			cnt++
+			item["id"] = idPrefix + strconv.Itoa(cnt)
+			items[item["id"]] = item
+			w.Header().Set("Location", "/items/"+item["id"])
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(item)
+
+		case r.Method == http.MethodGet && items[id] != nil:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(items[id])
+
+		case r.Method == http.MethodDelete && items[id] != nil:
+			delete(items, id)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func Test_Exchange_Steps(t *testing.T) {
+	// --- When ---
+	gld := NewExchange(Open(t, "testdata/scenario.yaml", nil))
+
+	// --- Then ---
+	assert.Nil(t, gld.Request)
+	assert.Nil(t, gld.Response)
+	require.Len(t, gld.Steps, 4)
+	assert.Exactly(t, http.MethodPost, gld.Steps[0].Request.Method)
+	assert.Exactly(t, "/items", gld.Steps[0].Request.Path)
+	assert.Exactly(t, 201, gld.Steps[0].Response.StatusCode)
+	assert.Exactly(t, http.MethodDelete, gld.Steps[2].Request.Method)
+	assert.Exactly(t, 204, gld.Steps[2].Response.StatusCode)
+}
+
+func Test_Exchange_Steps_AssertHandler(t *testing.T) {
+	// --- Given ---
+	gld := NewExchange(Open(t, "testdata/scenario.yaml", nil))
+
+	// --- When ---
+	req, rsp := gld.AssertHandler(itemsHandler(""))
+
+	// --- Then ---
+	assert.Exactly(t, http.MethodGet, req.Method)
+	assert.Exactly(t, http.StatusNotFound, rsp.StatusCode)
+}
+
+func Test_Exchange_Steps_Assert(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(itemsHandler(""))
+	defer srv.Close()
+
+	gld := NewExchange(Open(t, "testdata/scenario.yaml", nil))
+	for _, step := range gld.Steps {
+		step.Request.Scheme = "http"
+	}
+
+	// --- When ---
+	_, rsp := gld.Assert(srv.Listener.Addr().String())
+
+	// --- Then ---
+	assert.Exactly(t, http.StatusNotFound, rsp.StatusCode)
+}
+
+func Test_Exchange_Steps_WithRequest(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", errors.New("exchange with steps cannot have request or response"))
+
+	src := "request:\n  method: GET\n  path: /\nsteps:\n  - request:\n      method: GET\n      path: /\n    response:\n      statusCode: 200\n"
+
+	// --- When ---
+	NewExchange(mck, strings.NewReader(src))
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Exchange_Steps_FailureNamesStep(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"step 2 GET /items/%%20: expected response status code %d got %d",
+		200,
+		404,
+	)
+
+	src := "steps:\n" +
+		"  - request:\n      method: GET\n      path: /\n" +
+		"    response:\n      statusCode: 200\n" +
+		"  - request:\n      method: GET\n      path: /items/%20\n" +
+		"    response:\n      statusCode: 200\n"
+	gld := NewExchange(mck, strings.NewReader(src))
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	// --- When ---
+	gld.AssertHandler(h)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Exchange_Steps_Capture(t *testing.T) {
+	// --- Given ---
+	gld := NewExchange(Open(t, "testdata/scenario_capture.yaml", nil))
+
+	// --- When ---
+	req, rsp := gld.AssertHandler(itemsHandler("abc"))
+
+	// --- Then ---
+	assert.Exactly(t, http.MethodDelete, req.Method)
+	assert.Exactly(t, "/items/abc1", req.URL.Path)
+	assert.Exactly(t, http.StatusNoContent, rsp.StatusCode)
+
+	exp := Map{"itemId": "abc1", "location": "/items/abc1", "name": "item"}
+	assert.Exactly(t, exp, gld.Captured())
+
+	// Golden file request is not modified.
+	assert.Exactly(t, "/items/${itemId}", gld.Steps[2].Request.Path)
+}
+
+func Test_Exchange_Steps_CaptureWithTemplateData(t *testing.T) {
+	// --- Given ---
+	data := struct {
+		Token string
+		Name  string
+	}{"tok", "item"}
+	gld := NewExchange(Open(t, "testdata/scenario_capture.tpl.yaml", data, TplStrict()))
+
+	// --- When ---
+	req, rsp := gld.AssertHandler(itemsHandler("abc"))
+
+	// --- Then ---
+	assert.Exactly(t, "/items/abc1", req.URL.Path)
+	assert.Exactly(t, "Bearer tok", req.Header.Get("Authorization"))
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+	assert.Exactly(t, Map{"itemId": "abc1"}, gld.Captured())
+}
+
+// jobHandler returns HTTP handler responding with 202 Accepted status code
+// to the first n requests and with 200 OK after that.
+func jobHandler(n int) (http.Handler, *int) {
+	var cnt int
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cnt++
+		w.Header().Set("Content-Type", "application/json")
+		if cnt <= n {
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"status":"pending"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"done"}`))
+	}), &cnt
+}
+
+func Test_Exchange_AssertHandler_ExPoll(t *testing.T) {
+	// --- Given ---
+	h, cnt := jobHandler(2)
+	gld := NewExchange(Open(t, "testdata/exchange_job.yaml", nil))
+
+	// --- When ---
+	_, rsp := gld.AssertHandler(h, ExPoll(time.Second, time.Millisecond))
+
+	// --- Then ---
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+	assert.Exactly(t, 3, *cnt)
+}
+
+func Test_Exchange_AssertHandler_ExAttempts(t *testing.T) {
+	// --- Given ---
+	h, cnt := jobHandler(5)
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatalf", "expected response status code %d got %d", 200, 202)
+
+	gld := NewExchange(Open(mck, "testdata/exchange_job.yaml", nil))
+
+	// --- When ---
+	_, rsp := gld.AssertHandler(
+		h,
+		ExPoll(time.Second, time.Millisecond),
+		ExAttempts(3),
+	)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+	assert.Exactly(t, http.StatusAccepted, rsp.StatusCode)
+	assert.Exactly(t, 3, *cnt)
+}
+
+func Test_Exchange_Assert_ExPoll_Timeout(t *testing.T) {
+	// --- Given ---
+	h, cnt := jobHandler(1000)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatalf", "expected response status code %d got %d", 200, 202)
+
+	gld := NewExchange(Open(mck, "testdata/exchange_job.yaml", nil))
+	gld.Request.Scheme = "http"
+
+	// --- When ---
+	_, rsp := gld.Assert(
+		srv.Listener.Addr().String(),
+		ExPoll(100*time.Millisecond, 20*time.Millisecond),
+	)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+	assert.Exactly(t, http.StatusAccepted, rsp.StatusCode)
+	assert.True(t, *cnt > 1 && *cnt <= 6)
+}
+
+func Test_Exchange_Limits(t *testing.T) {
+	// --- When ---
+	gld := NewExchange(Open(t, "testdata/exchange_limits.yaml", nil))
+
+	// --- Then ---
+	assert.Exactly(t, 50*time.Millisecond, gld.Response.MaxDuration)
+	assert.Exactly(t, int64(20), gld.Response.MaxBodyBytes)
+
+	dst := &bytes.Buffer{}
+	_, err := gld.WriteTo(dst)
+	require.NoError(t, err)
+	assert.Contains(t, dst.String(), "maxDuration: 50ms\n")
+	assert.Contains(t, dst.String(), "maxBodyBytes: 20\n")
+}
+
+func Test_Exchange_AssertHandler_Limits(t *testing.T) {
+	// --- Given ---
+	gld := NewExchange(Open(t, "testdata/exchange_limits.yaml", nil))
+
+	// --- When ---
+	_, rsp := gld.AssertHandler(successHandler())
+
+	// --- Then ---
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+}
+
+func Test_Exchange_Assert_MaxDuration(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(60 * time.Millisecond)
+			successHandler().ServeHTTP(w, r)
+		}),
+	)
+	defer srv.Close()
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"expected response duration at most %s got %s",
+		50*time.Millisecond,
+		mock.AnythingOfType("time.Duration"),
+	)
+
+	gld := NewExchange(Open(mck, "testdata/exchange_limits.yaml", nil))
+	gld.Request.Scheme = "http"
+
+	// --- When ---
+	gld.Assert(srv.Listener.Addr().String())
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Exchange_AssertHandler_MaxBodyBytes(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"expected response body size at most %d bytes got %d",
+		int64(20),
+		30,
+	)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success": true, "more": "x"}`))
+	})
+
+	gld := NewExchange(Open(mck, "testdata/exchange_limits.yaml", nil))
+
+	// --- When ---
+	gld.AssertHandler(h)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
@@ -2,6 +2,8 @@ package golden
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 // Golden file body type.
@@ -13,10 +15,19 @@ const (
 	TypeJSON = "json"
 )
 
+// EnvUpdate is the name of environment variable which, when set to "1" or
+// "true", turns on the update mode in which Snapshot writes golden files
+// instead of comparing with them.
+const EnvUpdate = "GOLDEN_UPDATE"
+
 // ErrUnknownUnmarshaler represents an error when unmarshaler for golden file
 // body cannot be found.
 var ErrUnknownUnmarshaler = errors.New("unknown unmarshaler")
 
+// ErrNoExchange represents an error when golden file exchange matching
+// HTTP request cannot be found.
+var ErrNoExchange = errors.New("no matching exchange")
+
 // T is a subset of testing.TB interface.
 // It's primarily used to test golden package but can be used to implement
 // custom actions to be taken on errors.
@@ -32,3 +43,45 @@ type T interface {
 	// Helper may be called simultaneously from multiple goroutines.
 	Helper()
 }
+
+// cleaner is implemented by test managers which can register functions to
+// be called when the test completes (e.g. testing.T).
+type cleaner interface {
+	// Cleanup registers a function to be called when the test
+	// (or subtest) and all its subtests complete.
+	Cleanup(func())
+}
+
+// logger is implemented by test managers which can log messages
+// (e.g. testing.T).
+type logger interface {
+	// Logf formats its arguments and records the text in the error log.
+	Logf(format string, args ...interface{})
+}
+
+// errT is a test manager collecting failures instead of failing the test.
+// It's used to run assertions which must not stop the test.
+type errT struct {
+	errs []string // Collected failure messages.
+}
+
+// Fatal collects the failure message.
+func (et *errT) Fatal(args ...interface{}) {
+	et.errs = append(et.errs, fmt.Sprint(args...))
+}
+
+// Fatalf collects the failure message.
+func (et *errT) Fatalf(format string, args ...interface{}) {
+	et.errs = append(et.errs, fmt.Sprintf(format, args...))
+}
+
+// Helper does nothing.
+func (et *errT) Helper() {}
+
+// err returns error describing collected failures or nil if there were none.
+func (et *errT) err() error {
+	if len(et.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(et.errs, "\n"))
+}
@@ -4,11 +4,16 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/textproto"
 	"net/url"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -25,44 +30,152 @@ func TplDelims(left, right string) tplOpt {
 	}
 }
 
+// TplFuncs adds functions to the template function map. Functions with
+// the same names as the ones returned by FuncMap override them.
+func TplFuncs(funcs template.FuncMap) tplOpt {
+	return func(tpl *template.Template) *template.Template {
+		return tpl.Funcs(funcs)
+	}
+}
+
+// TplStrict makes template execution fail when the template references
+// a key missing from the template data map. Without it, the missing key is
+// rendered as "<no value>". The error reports golden file path and line of
+// the missing key.
+func TplStrict() tplOpt {
+	return TplOption("missingkey=error")
+}
+
+// TplOption sets options for the template. See template.Option for
+// available options. For example, to fail on missing map keys:
+//
+//   Open(t, "golden.yml", data, TplOption("missingkey=error"))
+//
+func TplOption(opts ...string) tplOpt {
+	return func(tpl *template.Template) *template.Template {
+		return tpl.Option(opts...)
+	}
+}
+
 // Open reads golden file pointed by pth and returns it as a byte slice.
 //
 // If data is not nil the golden file pointed by pth is treated as a template
-// and applies a parsed template to the specified data object.
+// and applies a parsed template to the specified data object. Templates have
+// access to functions returned by FuncMap and the include function which
+// executes golden file fragment, pointed by path relative to the opened
+// file, as a template:
+//
+//   headers:
+//   {{ include "common/headers.yaml" . | indent 2 }}
+//
+// Golden file fragments can also be included using the !include YAML tag
+// with path relative to the including file. Included fragments are executed
+// as templates the same way as the golden file:
+//
+//   headers: !include common/headers.yaml
+//
+// Golden files including themselves, directly or indirectly, with either
+// the include function or the !include tag fail with ErrIncludeCycle.
+//
+// Golden file may extend other golden file, pointed by path relative to it,
+// overriding only some of its fields. Mappings are deep merged, headers are
+// merged by name and JSON bodies are deep merged:
+//
+//   extends: common/base.yaml
+//
+// When data is a struct, or a pointer to a struct, fields referenced in
+// the template are checked to exist on it before the template is executed.
+// The error lists all unknown fields.
 //
 // You can set template action delimiters using TplDelims function:
 //
 //   Open(t, "golden.yml", data, TplDelims("[[", "]]"))
 //
+// Custom template functions can be added using TplFuncs function:
+//
+//   Open(t, "golden.yml", data, TplFuncs(template.FuncMap{"jwt": signJWT}))
+//
 func Open(t T, pth string, data interface{}, opts ...tplOpt) (T, io.Reader) {
-	content, err := ioutil.ReadFile(pth)
+	ld := &loader{
+		read: ioutil.ReadFile,
+		rel:  relPath,
+		data: data,
+		opts: opts,
+	}
+
+	content, err := ld.load(pth)
 	if err != nil {
 		t.Fatal(err)
 		return t, nil
 	}
 
-	if data != nil {
-		tpl := template.New("golden")
-		for _, opt := range opts {
-			tpl = opt(tpl)
-		}
+	return t, bytes.NewReader(content)
+}
 
-		tpl, err := tpl.Parse(string(content))
-		if err != nil {
-			t.Fatal(err)
-			return t, nil
-		}
-		buf := &bytes.Buffer{}
-		if err := tpl.Execute(buf, data); err != nil {
-			t.Fatal(err)
-			return t, nil
-		}
-		return t, buf
+// OpenFS reads golden file pointed by pth from fsys and returns it as
+// a byte slice. It works the same way as Open, but golden files, including
+// included and extended ones, are read from fsys (e.g. embed.FS) and paths
+// are slash separated as required by fs.FS.
+func OpenFS(t T, fsys fs.FS, pth string, data interface{}, opts ...tplOpt) (T, io.Reader) {
+	ld := &loader{
+		read: func(name string) ([]byte, error) {
+			return fs.ReadFile(fsys, name)
+		},
+		rel:  relFS,
+		data: data,
+		opts: opts,
+	}
+
+	content, err := ld.load(pth)
+	if err != nil {
+		t.Fatal(err)
+		return t, nil
 	}
 
 	return t, bytes.NewReader(content)
 }
 
+// namer is implemented by test managers which have names.
+type namer interface {
+	Name() string
+}
+
+// ForTest returns golden file path for the test t. The path is built from
+// the test name, each subtest becomes a directory, and characters which
+// are not safe in file names are replaced with underscores. For example,
+// golden file path for subtest "sub case" of TestFoo is:
+//
+//   testdata/TestFoo/sub_case.yaml
+//
+func ForTest(t namer) string {
+	parts := strings.Split(t.Name(), "/")
+	for i, part := range parts {
+		parts[i] = sanitizeName(part)
+	}
+	return filepath.Join("testdata", filepath.Join(parts...)+".yaml")
+}
+
+// sanitizeName replaces characters which are not safe in file names
+// with underscores.
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-' || r == '_' || r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	if strings.Trim(name, ".") == "" {
+		name = strings.Repeat("_", len(name))
+	}
+	if name == "" {
+		name = "_"
+	}
+	return name
+}
+
 // Map is a helper type for constructing template data.
 type Map map[string]interface{}
 
@@ -72,6 +185,43 @@ func (m Map) Add(key string, val interface{}) Map {
 	return m
 }
 
+// Merge adds all keys and values from src to the map and returns map for
+// chaining. Existing keys are overwritten.
+func (m Map) Merge(src Map) Map {
+	for key, val := range src {
+		m[key] = val
+	}
+	return m
+}
+
+// capRef matches references to captured values like ${itemId}.
+var capRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expand replaces references to captured values (e.g. ${itemId}) in s with
+// values from data. References to values missing from data are returned
+// unchanged.
+func expand(s string, data Map) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return capRef.ReplaceAllStringFunc(s, func(ref string) string {
+		val, ok := data[ref[2:len(ref)-1]]
+		if !ok {
+			return ref
+		}
+		return fmt.Sprint(val)
+	})
+}
+
+// urlPath returns URL path or "/" when the path is empty, which is the case
+// for client requests to bare host URLs (e.g. http://example.com).
+func urlPath(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
 // headers2Lines returns headers in wire format as slice of strings.
 // Returned lines do not have trailing \r\n characters and the last
 // empty line is removed.
@@ -120,6 +270,18 @@ func readBody(t T, rc io.ReadCloser) ([]byte, io.ReadCloser) {
 	return []byte(strings.Join(lns, "\n")), ioutil.NopCloser(buf)
 }
 
+// bodyType returns golden file body type inferred from Content-Type header.
+func bodyType(hs http.Header) string {
+	mt, _, err := mime.ParseMediaType(hs.Get("Content-Type"))
+	if err != nil {
+		return TypeText
+	}
+	if mt == "application/json" || strings.HasSuffix(mt, "+json") {
+		return TypeJSON
+	}
+	return TypeText
+}
+
 // bindQuery decodes HTTP query string to a struct v.
 // The tag is used to locate custom field aliases. See
 // https://github.com/gorilla/schema for details.
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -128,3 +129,112 @@ func Test_helpers_unmarshalBody_Bytes(t *testing.T) {
 	// --- Then ---
 	assert.Exactly(t, []byte("Line 1\nLine 2"), m)
 }
+
+func Test_helpers_bodyType(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		ct  string
+		exp string
+	}{
+		{"json", "application/json", TypeJSON},
+		{"json with charset", "application/json; charset=utf-8", TypeJSON},
+		{"json suffix", "application/problem+json", TypeJSON},
+		{"text", "text/plain", TypeText},
+		{"empty", "", TypeText},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- Given ---
+			hs := http.Header{}
+			hs.Set("Content-Type", tc.ct)
+
+			// --- When ---
+			got := bodyType(hs)
+
+			// --- Then ---
+			assert.Exactly(t, tc.exp, got)
+		})
+	}
+}
+
+func Test_helpers_Map_Merge(t *testing.T) {
+	// --- Given ---
+	data := make(Map).Add("key1", "val1").Add("key2", 2)
+
+	// --- When ---
+	got := data.Merge(Map{"key2": 3, "key3": "val3"})
+
+	// --- Then ---
+	exp := Map{"key1": "val1", "key2": 3, "key3": "val3"}
+	assert.Exactly(t, exp, got)
+	assert.Exactly(t, exp, data)
+}
+
+func Test_helpers_expand(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		s   string
+		exp string
+	}{
+		{"reference", "/items/${id}", "/items/1"},
+		{"many references", "${id}-${name}", "1-item"},
+		{"missing value", "/items/${other}", "/items/${other}"},
+		{"no references", "/items/{{ .id }}", "/items/{{ .id }}"},
+		{"invalid reference", "${ id }", "${ id }"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- When ---
+			got := expand(tc.s, Map{"id": 1, "name": "item"})
+
+			// --- Then ---
+			assert.Exactly(t, tc.exp, got)
+		})
+	}
+}
+
+func Test_ForTest(t *testing.T) {
+	// --- Then ---
+	assert.Exactly(t, filepath.Join("testdata", "Test_ForTest.yaml"), ForTest(t))
+
+	t.Run("sub case", func(t *testing.T) {
+		exp := filepath.Join("testdata", "Test_ForTest", "sub_case.yaml")
+		assert.Exactly(t, exp, ForTest(t))
+
+		t.Run("nested", func(t *testing.T) {
+			exp := filepath.Join("testdata", "Test_ForTest", "sub_case", "nested.yaml")
+			assert.Exactly(t, exp, ForTest(t))
+		})
+	})
+}
+
+func Test_sanitizeName(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		name string
+		exp  string
+	}{
+		{"safe", "Test-name_1.v2", "Test-name_1.v2"},
+		{"spaces", "sub case", "sub_case"},
+		{"unsafe", `a:b*c?"d<e>|f\g`, "a_b_c__d_e__f_g"},
+		{"unicode", "zażółć", "za____"},
+		{"dot", ".", "_"},
+		{"dots", "..", "__"},
+		{"empty", "", "_"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- When ---
+			got := sanitizeName(tc.name)
+
+			// --- Then ---
+			assert.Exactly(t, tc.exp, got)
+		})
+	}
+}
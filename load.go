@@ -0,0 +1,245 @@
+package golden
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxLoadFailures is the maximum number of failure messages kept in
+// the LoadReport.
+const maxLoadFailures = 10
+
+// loadCfg represents load runner configuration.
+type loadCfg struct {
+	requests    int           // Number of requests to make.
+	duration    time.Duration // Time to make requests for.
+	concurrency int           // Number of concurrent workers.
+	maxErrRate  float64       // Maximum acceptable error rate.
+	cli         *http.Client  // HTTP client used to make requests.
+}
+
+// loadOpt represents load runner option.
+type loadOpt func(cfg *loadCfg)
+
+// LoadRequests sets the number of requests Load makes. When used together
+// with LoadDuration Load stops when either limit is reached.
+func LoadRequests(n int) loadOpt {
+	return func(cfg *loadCfg) {
+		cfg.requests = n
+	}
+}
+
+// LoadDuration sets the time Load makes requests for. When used together
+// with LoadRequests Load stops when either limit is reached.
+func LoadDuration(d time.Duration) loadOpt {
+	return func(cfg *loadCfg) {
+		cfg.duration = d
+	}
+}
+
+// LoadConcurrency sets the number of concurrent workers making requests.
+// By default, there is only one worker.
+func LoadConcurrency(n int) loadOpt {
+	return func(cfg *loadCfg) {
+		cfg.concurrency = n
+	}
+}
+
+// LoadMaxErrorRate sets the maximum acceptable error rate (0.0 - 1.0).
+// By default, any error fails the test.
+func LoadMaxErrorRate(rate float64) loadOpt {
+	return func(cfg *loadCfg) {
+		cfg.maxErrRate = rate
+	}
+}
+
+// LoadClient sets HTTP client used to make requests.
+func LoadClient(cli *http.Client) loadOpt {
+	return func(cfg *loadCfg) {
+		cfg.cli = cli
+	}
+}
+
+// LoadReport represents load test results.
+type LoadReport struct {
+	Requests  int             // Number of requests made.
+	Errors    int             // Number of failed requests.
+	Duration  time.Duration   // Total load test duration.
+	Latencies []time.Duration // Sorted request latencies.
+	Failures  []string        // First few failure messages.
+}
+
+// Throughput returns number of requests per second.
+func (rep *LoadReport) Throughput() float64 {
+	if rep.Duration == 0 {
+		return 0
+	}
+	return float64(rep.Requests) / rep.Duration.Seconds()
+}
+
+// ErrorRate returns the ratio of failed requests to all requests.
+func (rep *LoadReport) ErrorRate() float64 {
+	if rep.Requests == 0 {
+		return 0
+	}
+	return float64(rep.Errors) / float64(rep.Requests)
+}
+
+// Percentile returns the latency percentile p (0 - 100) using the nearest
+// rank method.
+func (rep *LoadReport) Percentile(p float64) time.Duration {
+	if len(rep.Latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(rep.Latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(rep.Latencies) {
+		rank = len(rep.Latencies)
+	}
+	return rep.Latencies[rank-1]
+}
+
+// String implements fmt.Stringer interface.
+func (rep *LoadReport) String() string {
+	return fmt.Sprintf(
+		"requests: %d, errors: %d (%.2f%%), duration: %s, "+
+			"throughput: %.2f req/s, latency p50: %s, p90: %s, p99: %s",
+		rep.Requests,
+		rep.Errors,
+		rep.ErrorRate()*100,
+		rep.Duration,
+		rep.Throughput(),
+		rep.Percentile(50),
+		rep.Percentile(90),
+		rep.Percentile(99),
+	)
+}
+
+// add adds request result to the report.
+func (rep *LoadReport) add(d time.Duration, err error) {
+	rep.Requests++
+	rep.Latencies = append(rep.Latencies, d)
+	if err != nil {
+		rep.Errors++
+		if len(rep.Failures) < maxLoadFailures {
+			rep.Failures = append(rep.Failures, err.Error())
+		}
+	}
+}
+
+// Load makes requests described by exchanges to host concurrently and
+// asserts every response the same way Exchange.Assert does. Exchanges are
+// used in round-robin fashion. By default, every exchange is used once,
+// use LoadRequests and LoadDuration options to change it.
+//
+// Exchanges with steps are not supported.
+//
+// The test fails when the error rate exceeds the maximum acceptable error
+// rate (see LoadMaxErrorRate). The returned report contains throughput,
+// error rate and latency statistics.
+func Load(t T, host string, exs []*Exchange, opts ...loadOpt) *LoadReport {
+	t.Helper()
+
+	cfg := &loadCfg{concurrency: 1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.requests == 0 && cfg.duration == 0 {
+		cfg.requests = len(exs)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	if len(exs) == 0 {
+		t.Fatal(errors.New("load test needs at least one exchange"))
+		return nil
+	}
+	for _, ex := range exs {
+		if ex != nil && len(ex.Steps) > 0 {
+			t.Fatal(errors.New("load test does not support exchanges with steps"))
+			return nil
+		}
+		if ex == nil || ex.Request == nil || ex.Response == nil {
+			t.Fatal(errors.New("load test exchange needs request and response"))
+			return nil
+		}
+	}
+
+	ecfg := &exCfg{ctx: context.Background(), cli: cfg.cli}
+	rep := &LoadReport{}
+	start := time.Now()
+
+	var cnt int
+	var mx sync.Mutex
+	next := func() (int, bool) {
+		mx.Lock()
+		defer mx.Unlock()
+		if cfg.requests > 0 && cnt >= cfg.requests {
+			return 0, false
+		}
+		if cfg.duration > 0 && time.Since(start) >= cfg.duration {
+			return 0, false
+		}
+		cnt++
+		return cnt - 1, true
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				n, ok := next()
+				if !ok {
+					return
+				}
+				d, err := loadCheck(exs[n%len(exs)], host, ecfg)
+				mx.Lock()
+				rep.add(d, err)
+				mx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	rep.Duration = time.Since(start)
+	sort.Slice(rep.Latencies, func(i, j int) bool {
+		return rep.Latencies[i] < rep.Latencies[j]
+	})
+
+	if rep.ErrorRate() > cfg.maxErrRate {
+		t.Fatalf("load test error rate too high: %s\n%s", rep, rep.Failures[0])
+		return rep
+	}
+
+	return rep
+}
+
+// loadCheck makes the request described by exchange to host and checks
+// the response. It returns the time it took to get the response and error
+// describing the mismatch or nil.
+func loadCheck(ex *Exchange, host string, cfg *exCfg) (time.Duration, error) {
+	et := &errT{}
+	rsp := *ex.Response
+	rsp.t = et
+	cp := &Exchange{Request: ex.Request, Response: &rsp, t: et}
+
+	req, got, d := cp.do(host, cfg)
+	if req == nil {
+		return d, et.err()
+	}
+
+	rsp.Assert(got)
+	rsp.AssertDuration(d)
+	return d, et.err()
+}
@@ -0,0 +1,189 @@
+package golden
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/rzajac/golden/internal"
+)
+
+func Test_Load(t *testing.T) {
+	// --- Given ---
+	var cnt int64
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(&cnt, 1)
+			successHandler().ServeHTTP(w, r)
+		}),
+	)
+	defer srv.Close()
+
+	ex := NewExchange(Open(t, "testdata/exchange_limits.yaml", nil))
+	ex.Request.Scheme = "http"
+	ex.Response.MaxDuration = time.Second
+
+	// --- When ---
+	rep := Load(
+		t,
+		srv.Listener.Addr().String(),
+		[]*Exchange{ex},
+		LoadRequests(20),
+		LoadConcurrency(4),
+	)
+
+	// --- Then ---
+	require.NotNil(t, rep)
+	assert.Exactly(t, int64(20), atomic.LoadInt64(&cnt))
+	assert.Exactly(t, 20, rep.Requests)
+	assert.Exactly(t, 0, rep.Errors)
+	assert.Exactly(t, 0.0, rep.ErrorRate())
+	assert.Len(t, rep.Latencies, 20)
+	assert.True(t, rep.Throughput() > 0)
+	assert.True(t, rep.Percentile(50) <= rep.Percentile(99))
+	assert.Contains(t, rep.String(), "requests: 20, errors: 0 (0.00%)")
+}
+
+func Test_Load_LoadDuration(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(successHandler())
+	defer srv.Close()
+
+	ex := NewExchange(Open(t, "testdata/exchange_limits.yaml", nil))
+	ex.Request.Scheme = "http"
+	ex.Response.MaxDuration = time.Second
+
+	// --- When ---
+	rep := Load(
+		t,
+		srv.Listener.Addr().String(),
+		[]*Exchange{ex},
+		LoadDuration(50*time.Millisecond),
+		LoadConcurrency(2),
+	)
+
+	// --- Then ---
+	assert.True(t, rep.Requests > 0)
+	assert.True(t, rep.Duration >= 50*time.Millisecond)
+}
+
+func Test_Load_Errors(t *testing.T) {
+	// --- Given ---
+	var cnt int64
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt64(&cnt, 1)%2 == 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			successHandler().ServeHTTP(w, r)
+		}),
+	)
+	defer srv.Close()
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"load test error rate too high: %s\n%s",
+		mock.AnythingOfType("*golden.LoadReport"),
+		"expected response status code 200 got 500",
+	)
+
+	ex := NewExchange(Open(mck, "testdata/exchange_limits.yaml", nil))
+	ex.Request.Scheme = "http"
+	ex.Response.MaxDuration = time.Second
+
+	// --- When ---
+	rep := Load(mck, srv.Listener.Addr().String(), []*Exchange{ex}, LoadRequests(10))
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+	assert.Exactly(t, 5, rep.Errors)
+	assert.Exactly(t, 0.5, rep.ErrorRate())
+	assert.Len(t, rep.Failures, 5)
+}
+
+func Test_Load_LoadMaxErrorRate(t *testing.T) {
+	// --- Given ---
+	var cnt int64
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt64(&cnt, 1)%5 == 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			successHandler().ServeHTTP(w, r)
+		}),
+	)
+	defer srv.Close()
+
+	ex := NewExchange(Open(t, "testdata/exchange_limits.yaml", nil))
+	ex.Request.Scheme = "http"
+	ex.Response.MaxDuration = time.Second
+
+	// --- When ---
+	rep := Load(
+		t,
+		srv.Listener.Addr().String(),
+		[]*Exchange{ex},
+		LoadRequests(10),
+		LoadMaxErrorRate(0.2),
+	)
+
+	// --- Then ---
+	assert.Exactly(t, 2, rep.Errors)
+}
+
+func Test_Load_InvalidExchanges(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		exs []*Exchange
+		exp string
+	}{
+		{"none", nil, "load test needs at least one exchange"},
+		{"nil", []*Exchange{nil}, "load test exchange needs request and response"},
+		{"no response", []*Exchange{{Request: &Request{}}}, "load test exchange needs request and response"},
+		{"steps", []*Exchange{{Steps: []*Exchange{{}}}}, "load test does not support exchanges with steps"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- Given ---
+			mck := &TMock{}
+			mck.On("Helper")
+			mck.On("Fatal", errors.New(tc.exp))
+
+			// --- When ---
+			rep := Load(mck, "example.com", tc.exs)
+
+			// --- Then ---
+			assert.Nil(t, rep)
+			mck.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_LoadReport_Percentile(t *testing.T) {
+	// --- Given ---
+	rep := &LoadReport{}
+	for i := 1; i <= 10; i++ {
+		rep.Latencies = append(rep.Latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	// --- Then ---
+	assert.Exactly(t, 5*time.Millisecond, rep.Percentile(50))
+	assert.Exactly(t, 9*time.Millisecond, rep.Percentile(90))
+	assert.Exactly(t, 10*time.Millisecond, rep.Percentile(99))
+	assert.Exactly(t, 1*time.Millisecond, rep.Percentile(0))
+	assert.True(t, strings.HasPrefix((&LoadReport{}).String(), "requests: 0"))
+}
@@ -0,0 +1,339 @@
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tagInclude is the YAML tag used to include golden file fragments.
+const tagInclude = "!include"
+
+// keyExtends is the golden file key pointing to the golden file it extends.
+const keyExtends = "extends"
+
+// ErrIncludeCycle represents an error when golden file includes itself
+// directly or indirectly.
+var ErrIncludeCycle = errors.New("golden file include cycle")
+
+// loader loads golden files.
+type loader struct {
+	read  func(string) ([]byte, error)  // Reads file pointed by path.
+	rel   func(pth, name string) string // Resolves name relative to pth.
+	data  interface{}                   // Template data.
+	opts  []tplOpt                      // Template options.
+	stack []string                      // Paths of files being loaded.
+}
+
+// load reads golden file pointed by pth, executes it as a template when
+// template data is not nil, resolves !include tags and the extends key.
+// Golden files using neither are returned unchanged.
+func (ld *loader) load(pth string) ([]byte, error) {
+	if err := ld.push(pth); err != nil {
+		return nil, err
+	}
+	defer ld.pop()
+
+	content, err := ld.read(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	if ld.data != nil {
+		content, err = ld.execute(pth, content, ld.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !bytes.Contains(content, []byte(tagInclude)) &&
+		!bytes.Contains(content, []byte(keyExtends)) {
+
+		return content, nil
+	}
+
+	// Content which is not YAML or doesn't use !include tags nor extends key
+	// is returned unchanged, any decoding errors are reported by its users.
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(content, doc); err != nil {
+		return content, nil
+	}
+	if !hasTag(doc, tagInclude) && extendsNode(doc) == nil {
+		return content, nil
+	}
+
+	if err := ld.include(pth, doc); err != nil {
+		return nil, err
+	}
+	if err := ld.extend(pth, doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
+
+// hasTag returns true if node or any of its descendants has the tag.
+func hasTag(node *yaml.Node, tag string) bool {
+	if node.Tag == tag {
+		return true
+	}
+	for _, n := range node.Content {
+		if hasTag(n, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// extendsNode returns the value node of the top level extends key of
+// the document or nil if there is none.
+func extendsNode(doc *yaml.Node) *yaml.Node {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return mappingValue(doc.Content[0], keyExtends)
+}
+
+// push adds pth to the stack of paths being loaded. It returns an error
+// wrapping ErrIncludeCycle if pth is already being loaded.
+func (ld *loader) push(pth string) error {
+	for _, p := range ld.stack {
+		if p == pth {
+			return fmt.Errorf("%w: %s", ErrIncludeCycle, pth)
+		}
+	}
+	ld.stack = append(ld.stack, pth)
+	return nil
+}
+
+// pop removes the last path from the stack of paths being loaded.
+func (ld *loader) pop() {
+	ld.stack = ld.stack[:len(ld.stack)-1]
+}
+
+// extend merges golden file document with the golden file it extends, if
+// it declares the extends key. The extended golden file path is relative
+// to pth.
+func (ld *loader) extend(pth string, doc *yaml.Node) error {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	root := doc.Content[0]
+
+	var base string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == keyExtends {
+			base = root.Content[i+1].Value
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+	if base == "" {
+		return nil
+	}
+
+	bpth := ld.rel(pth, base)
+	content, err := ld.load(bpth)
+	if err != nil {
+		return err
+	}
+
+	bdoc := &yaml.Node{}
+	if err := yaml.Unmarshal(content, bdoc); err != nil {
+		return fmt.Errorf("%s: %w", bpth, err)
+	}
+	if len(bdoc.Content) == 0 {
+		return nil
+	}
+
+	merged, err := mergeNodes(bdoc.Content[0], root)
+	if err != nil {
+		return fmt.Errorf("%s: %w", pth, err)
+	}
+	doc.Content[0] = merged
+	return nil
+}
+
+// relPath returns name resolved relative to the directory of file pointed
+// by OS path pth.
+func relPath(pth, name string) string {
+	return filepath.Join(filepath.Dir(pth), name)
+}
+
+// relFS returns name resolved relative to the directory of file pointed by
+// fs.FS path pth.
+func relFS(pth, name string) string {
+	return path.Join(path.Dir(pth), name)
+}
+
+// mergeNodes deep merges over YAML node onto base YAML node. Mappings are
+// merged recursively, headers are merged by header name and JSON bodies
+// are deep merged. All other values from over replace the ones in base.
+func mergeNodes(base, over *yaml.Node) (*yaml.Node, error) {
+	if base.Kind != yaml.MappingNode || over.Kind != yaml.MappingNode {
+		return over, nil
+	}
+
+	bodyType := mappingValue(over, "bodyType")
+	if bodyType == nil {
+		bodyType = mappingValue(base, "bodyType")
+	}
+
+	merged := *base
+	merged.Content = append([]*yaml.Node{}, base.Content...)
+
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		key, val := over.Content[i], over.Content[i+1]
+
+		idx := -1
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				idx = j + 1
+				break
+			}
+		}
+		if idx == -1 {
+			merged.Content = append(merged.Content, key, val)
+			continue
+		}
+
+		bval := merged.Content[idx]
+		switch {
+		case key.Value == "headers" &&
+			bval.Kind == yaml.SequenceNode && val.Kind == yaml.SequenceNode:
+
+			merged.Content[idx] = mergeHeaders(bval, val)
+
+		case key.Value == "body" && bodyType != nil && bodyType.Value == TypeJSON &&
+			bval.Kind == yaml.ScalarNode && val.Kind == yaml.ScalarNode:
+
+			body, err := mergeJSON(bval.Value, val.Value)
+			if err != nil {
+				return nil, err
+			}
+			node := *val
+			node.Value = body
+			node.Style = yaml.LiteralStyle
+			merged.Content[idx] = &node
+
+		default:
+			node, err := mergeNodes(bval, val)
+			if err != nil {
+				return nil, err
+			}
+			merged.Content[idx] = node
+		}
+	}
+
+	return &merged, nil
+}
+
+// mappingValue returns value node for key in mapping node or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mergeHeaders merges header lines. Headers defined in over replace all
+// headers with the same name in base.
+func mergeHeaders(base, over *yaml.Node) *yaml.Node {
+	names := make(map[string]bool, len(over.Content))
+	for _, n := range over.Content {
+		names[headerName(n.Value)] = true
+	}
+
+	merged := *over
+	merged.Content = nil
+	for _, n := range base.Content {
+		if !names[headerName(n.Value)] {
+			merged.Content = append(merged.Content, n)
+		}
+	}
+	merged.Content = append(merged.Content, over.Content...)
+	return &merged
+}
+
+// headerName returns canonical header name from header line.
+func headerName(line string) string {
+	return http.CanonicalHeaderKey(strings.TrimSpace(strings.SplitN(line, ":", 2)[0]))
+}
+
+// mergeJSON deep merges over JSON document onto base JSON document.
+func mergeJSON(base, over string) (string, error) {
+	var bv, ov interface{}
+	if err := json.Unmarshal([]byte(base), &bv); err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal([]byte(over), &ov); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(mergeValues(bv, ov), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// mergeValues deep merges JSON objects. All other values from over
+// replace the ones in base.
+func mergeValues(base, over interface{}) interface{} {
+	bm, bok := base.(map[string]interface{})
+	om, ook := over.(map[string]interface{})
+	if !bok || !ook {
+		return over
+	}
+	for key, val := range om {
+		bm[key] = mergeValues(bm[key], val)
+	}
+	return bm
+}
+
+// include replaces nodes tagged with !include with the content of golden
+// file fragments they point to. Fragment paths are relative to pth.
+func (ld *loader) include(pth string, node *yaml.Node) error {
+	if node.Tag == tagInclude {
+		if node.Kind != yaml.ScalarNode {
+			return fmt.Errorf(
+				"%s:%d: %s tag needs a path",
+				pth,
+				node.Line,
+				tagInclude,
+			)
+		}
+
+		ipth := ld.rel(pth, node.Value)
+		content, err := ld.load(ipth)
+		if err != nil {
+			return err
+		}
+
+		doc := &yaml.Node{}
+		if err := yaml.Unmarshal(content, doc); err != nil {
+			return fmt.Errorf("%s: %w", ipth, err)
+		}
+		if len(doc.Content) == 0 {
+			*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+			return nil
+		}
+		*node = *doc.Content[0]
+		return nil
+	}
+
+	for _, n := range node.Content {
+		if err := ld.include(pth, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package golden
+
+import (
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	. "github.com/rzajac/golden/internal"
+)
+
+func Test_Open_include(t *testing.T) {
+	// --- When ---
+	gld := NewExchange(Open(t, "testdata/exchange_include.yaml", Map{"token": "tok"}))
+
+	// --- Then ---
+	exp := []string{
+		"Authorization: Bearer tok",
+		"Content-Type: application/json",
+	}
+	assert.Exactly(t, exp, gld.Request.Headers)
+	assert.Exactly(t, exp, gld.Response.Headers)
+	assert.Exactly(t, TypeJSON, gld.Request.Meta["bodyType"])
+	assert.Exactly(t, "{ \"key2\": \"val2\" }\n", gld.Request.Meta["body"])
+}
+
+func Test_Open_include_notTemplate(t *testing.T) {
+	// --- When ---
+	gld := NewExchange(Open(t, "testdata/exchange_include.yaml", nil))
+
+	// --- Then ---
+	exp := []string{
+		"Authorization: Bearer {{ .token }}",
+		"Content-Type: application/json",
+	}
+	assert.Exactly(t, exp, gld.Request.Headers)
+}
+
+func Test_Open_include_cycle(t *testing.T) {
+	// --- Given ---
+	dir := t.TempDir()
+	pth0 := filepath.Join(dir, "file0.yaml")
+	pth1 := filepath.Join(dir, "file1.yaml")
+	require.NoError(t, ioutil.WriteFile(pth0, []byte("meta: !include file1.yaml\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(pth1, []byte("key: !include file0.yaml\n"), 0600))
+
+	mck := &TMock{}
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		return errors.Is(err, ErrIncludeCycle)
+	}))
+
+	// --- When ---
+	Open(mck, pth0, nil)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Open_include_missingFile(t *testing.T) {
+	// --- Given ---
+	pth := filepath.Join(t.TempDir(), "file.yaml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte("meta: !include missing.yaml\n"), 0600))
+
+	mck := &TMock{}
+	mck.On("Fatal", mock.AnythingOfType("*fs.PathError"))
+
+	// --- When ---
+	Open(mck, pth, nil)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Open_extends(t *testing.T) {
+	// --- When ---
+	gld := NewExchange(Open(t, "testdata/exchange_extends.yaml", Map{"token": "tok"}))
+
+	// --- Then ---
+	assert.Exactly(t, "POST", gld.Request.Method)
+	assert.Exactly(t, "/some/path", gld.Request.Path)
+	expHs := []string{
+		"Content-Type: application/json",
+		"Authorization: Bearer other",
+	}
+	assert.Exactly(t, expHs, gld.Request.Headers)
+	assert.Exactly(t, "extended", gld.Request.Meta["name"])
+	assert.Exactly(t, []interface{}{"a", "b"}, gld.Request.Meta["tags"])
+	assert.Exactly(t, TypeJSON, gld.Request.BodyType)
+	assert.JSONEq(t, `{"key1": "val1", "obj": {"a": 1, "b": 3}}`, gld.Request.Body)
+
+	assert.Exactly(t, 201, gld.Response.StatusCode)
+	assert.Exactly(t, []string{"Content-Type: application/json"}, gld.Response.Headers)
+	assert.JSONEq(t, `{"success": true}`, gld.Response.Body)
+}
+
+func Test_Open_extends_cycle(t *testing.T) {
+	// --- Given ---
+	dir := t.TempDir()
+	pth0 := filepath.Join(dir, "file0.yaml")
+	pth1 := filepath.Join(dir, "file1.yaml")
+	require.NoError(t, ioutil.WriteFile(pth0, []byte("extends: file1.yaml\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(pth1, []byte("extends: file0.yaml\n"), 0600))
+
+	mck := &TMock{}
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		return errors.Is(err, ErrIncludeCycle)
+	}))
+
+	// --- When ---
+	Open(mck, pth0, nil)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_mergeHeaders(t *testing.T) {
+	// --- Given ---
+	base := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "Accept: text/plain"},
+		{Kind: yaml.ScalarNode, Value: "X-Multi: a"},
+		{Kind: yaml.ScalarNode, Value: "X-Multi: b"},
+	}}
+	over := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "x-multi: c"},
+	}}
+
+	// --- When ---
+	got := mergeHeaders(base, over)
+
+	// --- Then ---
+	require.Len(t, got.Content, 2)
+	assert.Exactly(t, "Accept: text/plain", got.Content[0].Value)
+	assert.Exactly(t, "x-multi: c", got.Content[1].Value)
+}
+
+func Test_OpenFS(t *testing.T) {
+	// --- Given ---
+	fsys := fstest.MapFS{
+		"api/exchange.yaml": {Data: []byte(
+			"extends: ../common/base.yaml\n" +
+				"request:\n" +
+				"  headers: !include ../common/headers.yaml\n" +
+				"  body: '{{ include \"body.txt\" . }}'\n",
+		)},
+		"api/body.txt": {Data: []byte("{{ .body }}")},
+		"common/base.yaml": {Data: []byte(
+			"request:\n" +
+				"  method: POST\n" +
+				"  path: /some/path\n" +
+				"response:\n" +
+				"  statusCode: 200\n",
+		)},
+		"common/headers.yaml": {Data: []byte("- 'Authorization: Bearer {{ .token }}'\n")},
+	}
+
+	// --- When ---
+	gld := NewExchange(OpenFS(t, fsys, "api/exchange.yaml", Map{"token": "tok", "body": "text"}))
+
+	// --- Then ---
+	assert.Exactly(t, "POST", gld.Request.Method)
+	assert.Exactly(t, "/some/path", gld.Request.Path)
+	assert.Exactly(t, []string{"Authorization: Bearer tok"}, gld.Request.Headers)
+	assert.Exactly(t, "text", gld.Request.Body)
+	assert.Exactly(t, 200, gld.Response.StatusCode)
+}
+
+func Test_OpenFS_notExisting(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		return errors.Is(err, fs.ErrNotExist)
+	}))
+
+	// --- When ---
+	OpenFS(mck, fstest.MapFS{}, "not_existing.yaml", nil)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Open_extends_notKey(t *testing.T) {
+	// --- Given ---
+	content := "# Comment.\n" +
+		"bodyType: text\n" +
+		"meta:\n" +
+		"    note: this file extends nothing\n" +
+		"body: |\n" +
+		"    class Foo extends Bar\n" +
+		"    tag !include\n" +
+		"---\n" +
+		"second: document\n"
+	pth := filepath.Join(t.TempDir(), "file.yaml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte(content), 0600))
+
+	// --- When ---
+	_, r := Open(t, pth, nil)
+
+	// --- Then ---
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Exactly(t, content, string(got))
+}
+
+func Test_Open_extends_nested(t *testing.T) {
+	// --- Given ---
+	content := "meta:\n  extends: base.yaml\n"
+	pth := filepath.Join(t.TempDir(), "file.yaml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte(content), 0600))
+
+	// --- When ---
+	_, r := Open(t, pth, nil)
+
+	// --- Then ---
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Exactly(t, content, string(got))
+}
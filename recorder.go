@@ -0,0 +1,296 @@
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Redacted is the value redacted header values are replaced with.
+const Redacted = "REDACTED"
+
+// recCfg represents recorder configuration.
+type recCfg struct {
+	// Returns golden file name for n-th (starting from 1) recorded exchange.
+	name func(n int, ex *Exchange) string
+
+	// Functions modifying exchanges before they are written.
+	redact []func(ex *Exchange)
+}
+
+// recOpt represents recorder option.
+type recOpt func(cfg *recCfg)
+
+// RecName sets function returning golden file name for n-th (starting
+// from 1) recorded exchange. By default, exchanges are recorded as a
+// numbered sequence of files: 001.yaml, 002.yaml, ...
+func RecName(fn func(n int, ex *Exchange) string) recOpt {
+	return func(cfg *recCfg) {
+		cfg.name = fn
+	}
+}
+
+// RecRedact adds function which is called with every recorded exchange
+// before it's written to golden file. It may be used to remove or replace
+// sensitive data.
+func RecRedact(fn func(ex *Exchange)) recOpt {
+	return func(cfg *recCfg) {
+		cfg.redact = append(cfg.redact, fn)
+	}
+}
+
+// RecRedactHeaders replaces values of named request and response headers
+// with Redacted before recorded exchange is written to golden file.
+func RecRedactHeaders(names ...string) recOpt {
+	return RecRedact(func(ex *Exchange) {
+		ex.Request.Headers = redactHeaders(ex.Request.Headers, names...)
+		ex.Response.Headers = redactHeaders(ex.Response.Headers, names...)
+	})
+}
+
+// redactHeaders replaces values of named headers in header lines
+// with Redacted.
+func redactHeaders(lines []string, names ...string) []string {
+	for i, ln := range lines {
+		key := strings.SplitN(ln, ":", 2)[0]
+		for _, name := range names {
+			if strings.EqualFold(strings.TrimSpace(key), name) {
+				lines[i] = key + ": " + Redacted
+			}
+		}
+	}
+	return lines
+}
+
+// volatileHeaders are response headers with values changing between
+// requests. They are not recorded, so recorded exchanges can be asserted
+// later.
+var volatileHeaders = []string{"Date"}
+
+// dropHeaders removes named headers from header lines.
+func dropHeaders(lines []string, names ...string) []string {
+	var ret []string
+	for _, ln := range lines {
+		key := strings.TrimSpace(strings.SplitN(ln, ":", 2)[0])
+		drop := false
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			ret = append(ret, ln)
+		}
+	}
+	return ret
+}
+
+// seqName is the default recorded golden file name function.
+func seqName(n int, _ *Exchange) string {
+	return fmt.Sprintf("%03d.yaml", n)
+}
+
+// recStore writes recorded exchanges to golden files in a directory.
+type recStore struct {
+	dir string     // Directory golden files are written to.
+	cfg *recCfg    // Recorder configuration.
+	cnt int        // Number of recorded exchanges.
+	mx  sync.Mutex // Guards cnt and golden file writes.
+}
+
+// newRecStore returns new instance of recStore. It creates dir if it
+// doesn't exist.
+func newRecStore(dir string, opts ...recOpt) (*recStore, error) {
+	cfg := &recCfg{name: seqName}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &recStore{dir: dir, cfg: cfg}, nil
+}
+
+// write writes exchange golden file.
+func (rs *recStore) write(ex *Exchange) error {
+	rs.mx.Lock()
+	defer rs.mx.Unlock()
+
+	ex.Response.Headers = dropHeaders(ex.Response.Headers, volatileHeaders...)
+	for _, fn := range rs.cfg.redact {
+		fn(ex)
+	}
+
+	rs.cnt++
+	pth := filepath.Join(rs.dir, rs.cfg.name(rs.cnt, ex))
+	fil, err := os.Create(pth)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ex.WriteTo(fil); err != nil {
+		_ = fil.Close()
+		return err
+	}
+	return fil.Close()
+}
+
+// count returns number of recorded exchanges.
+func (rs *recStore) count() int {
+	rs.mx.Lock()
+	defer rs.mx.Unlock()
+	return rs.cnt
+}
+
+// Recorder is an http.RoundTripper recording every request / response
+// made through it as Exchange golden files in a directory. Response headers
+// changing between requests, like Date, are not recorded.
+type Recorder struct {
+	rt    http.RoundTripper // Transport used to make requests.
+	store *recStore         // Recorded exchanges store.
+	t     T                 // Test manager.
+}
+
+// NewRecorder returns new instance of Recorder writing golden files to dir.
+// Requests are made using rt, when it's nil the http.DefaultTransport is
+// used.
+func NewRecorder(t T, dir string, rt http.RoundTripper, opts ...recOpt) *Recorder {
+	t.Helper()
+
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	store, err := newRecStore(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+		return nil
+	}
+
+	return &Recorder{
+		rt:    rt,
+		store: store,
+		t:     t,
+	}
+}
+
+// RoundTrip implements http.RoundTripper interface.
+func (rec *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec.t.Helper()
+
+	out := req.Clone(req.Context())
+	gReq := RequestFrom(rec.t, out)
+
+	rsp, err := rec.rt.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	ex := &Exchange{
+		Request:  gReq,
+		Response: ResponseFrom(rec.t, rsp),
+		t:        rec.t,
+	}
+	if err := rec.store.write(ex); err != nil {
+		rec.t.Fatal(err)
+		return nil, err
+	}
+
+	return rsp, nil
+}
+
+// Client returns HTTP client using the recorder as its transport.
+func (rec *Recorder) Client() *http.Client {
+	return &http.Client{Transport: rec}
+}
+
+// Count returns number of recorded exchanges.
+func (rec *Recorder) Count() int {
+	return rec.store.count()
+}
+
+// RecordHandler returns middleware recording every request served by h and
+// its response as Exchange golden files in dir. It accepts the same options
+// as NewRecorder.
+//
+// It's meant to be used to harvest golden files from handlers running in
+// a development environment, in which case a custom T implementation may be
+// used to decide what to do with errors.
+func RecordHandler(t T, dir string, h http.Handler, opts ...recOpt) http.Handler {
+	t.Helper()
+
+	store, err := newRecStore(dir, opts...)
+	if err != nil {
+		t.Fatal(err)
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gReq := RequestFrom(t, r)
+
+		cw := &captureWriter{ResponseWriter: w}
+		h.ServeHTTP(cw, r)
+
+		code := cw.code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		rsp := &http.Response{
+			Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+			StatusCode: code,
+			Proto:      r.Proto,
+			Header:     w.Header().Clone(),
+			Body:       ioutil.NopCloser(&cw.buf),
+		}
+
+		ex := &Exchange{
+			Request:  gReq,
+			Response: ResponseFrom(t, rsp),
+			t:        t,
+		}
+		if err := store.write(ex); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// captureWriter is http.ResponseWriter capturing response status code
+// and body.
+type captureWriter struct {
+	http.ResponseWriter
+
+	code int          // Response status code.
+	buf  bytes.Buffer // Response body.
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (cw *captureWriter) WriteHeader(code int) {
+	if cw.code == 0 {
+		cw.code = code
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter interface.
+func (cw *captureWriter) Write(p []byte) (int, error) {
+	if cw.code == 0 {
+		cw.code = http.StatusOK
+	}
+	cw.buf.Write(p)
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher interface.
+func (cw *captureWriter) Flush() {
+	if fl, ok := cw.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
@@ -0,0 +1,198 @@
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Recorder(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(successHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec := NewRecorder(t, dir, nil)
+	cli := rec.Client()
+
+	// --- When ---
+	rsp0, err := cli.Post(
+		srv.URL+"/some/path?key0=val0",
+		"application/json",
+		strings.NewReader(`{"key2":"val2"}`),
+	)
+	require.NoError(t, err)
+	rsp1, err := cli.Get(srv.URL + "/other/path")
+	require.NoError(t, err)
+
+	// --- Then ---
+	assert.Exactly(t, 2, rec.Count())
+
+	// Response bodies can still be read by the client.
+	body, err := ioutil.ReadAll(rsp0.Body)
+	require.NoError(t, err)
+	assert.Exactly(t, `{"success":true}`, string(body))
+	body, err = ioutil.ReadAll(rsp1.Body)
+	require.NoError(t, err)
+	assert.Exactly(t, `{"success":true}`, string(body))
+
+	ex0 := NewExchange(Open(t, filepath.Join(dir, "001.yaml"), nil))
+	assert.Exactly(t, "http", ex0.Request.Scheme)
+	assert.Exactly(t, http.MethodPost, ex0.Request.Method)
+	assert.Exactly(t, "/some/path", ex0.Request.Path)
+	assert.Exactly(t, "key0=val0", ex0.Request.Query)
+	assert.Exactly(t, TypeJSON, ex0.Request.BodyType)
+	assert.Exactly(t, `{"key2":"val2"}`, ex0.Request.Body)
+	assert.Exactly(t, 200, ex0.Response.StatusCode)
+	assert.Exactly(t, TypeJSON, ex0.Response.BodyType)
+	assert.Exactly(t, `{"success":true}`, ex0.Response.Body)
+
+	ex1 := NewExchange(Open(t, filepath.Join(dir, "002.yaml"), nil))
+	assert.Exactly(t, http.MethodGet, ex1.Request.Method)
+	assert.Exactly(t, "/other/path", ex1.Request.Path)
+}
+
+func Test_Recorder_RecName(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(successHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	name := func(n int, ex *Exchange) string {
+		return fmt.Sprintf("%d_%s.yaml", n, strings.ToLower(ex.Request.Method))
+	}
+	rec := NewRecorder(t, dir, http.DefaultTransport, RecName(name))
+
+	// --- When ---
+	_, err := rec.Client().Get(srv.URL + "/some/path")
+	require.NoError(t, err)
+
+	// --- Then ---
+	ex := NewExchange(Open(t, filepath.Join(dir, "1_get.yaml"), nil))
+	assert.Exactly(t, "/some/path", ex.Request.Path)
+}
+
+func Test_Recorder_RecRedactHeaders(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(successHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec := NewRecorder(t, dir, nil, RecRedactHeaders("authorization"))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/some/path", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+
+	// --- When ---
+	_, err = rec.Client().Do(req)
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Exactly(t, "Bearer token", req.Header.Get("Authorization"))
+
+	ex := NewExchange(Open(t, filepath.Join(dir, "001.yaml"), nil))
+	assert.Contains(t, ex.Request.Headers, "Authorization: "+Redacted)
+}
+
+func Test_RecordHandler(t *testing.T) {
+	// --- Given ---
+	dir := t.TempDir()
+	h := RecordHandler(t, dir, successHandler(), RecRedact(func(ex *Exchange) {
+		ex.Request.Meta = map[string]interface{}{"redacted": true}
+	}))
+
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/some/path?key0=val0",
+		strings.NewReader(`{"key2":"val2"}`),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	// --- When ---
+	h.ServeHTTP(rec, req)
+
+	// --- Then ---
+	assert.Exactly(t, http.StatusOK, rec.Code)
+	assert.Exactly(t, `{"success":true}`, rec.Body.String())
+
+	ex := NewExchange(Open(t, filepath.Join(dir, "001.yaml"), nil))
+	assert.Exactly(t, http.MethodPost, ex.Request.Method)
+	assert.Exactly(t, "/some/path", ex.Request.Path)
+	assert.Exactly(t, "key0=val0", ex.Request.Query)
+	assert.Exactly(t, `{"key2":"val2"}`, ex.Request.Body)
+	assert.Exactly(t, true, ex.Request.Meta["redacted"])
+	assert.Exactly(t, 200, ex.Response.StatusCode)
+	assert.Exactly(t, "200 OK", ex.Response.Status)
+	assert.Exactly(t, TypeJSON, ex.Response.BodyType)
+	assert.Exactly(t, `{"success":true}`, ex.Response.Body)
+
+	// Recorded exchange can be asserted against the same handler.
+	ex.AssertHandler(successHandler())
+}
+
+func Test_Recorder_DropsDateHeader(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(successHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec := NewRecorder(t, dir, nil)
+
+	// --- When ---
+	rsp, err := rec.Client().Get(srv.URL + "/some/path")
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+
+	// --- Then ---
+	assert.NotEmpty(t, rsp.Header.Get("Date"))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "001.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Date:")
+}
+
+func Test_dropHeaders(t *testing.T) {
+	// --- Given ---
+	lines := []string{
+		"Content-Type: application/json",
+		"Date: Mon, 02 Jan 2006 15:04:05 GMT",
+		"date: Mon, 02 Jan 2006 15:04:05 GMT",
+	}
+
+	// --- When ---
+	got := dropHeaders(lines, "Date")
+
+	// --- Then ---
+	assert.Exactly(t, []string{"Content-Type: application/json"}, got)
+}
+
+func Test_Recorder_ServerRoot(t *testing.T) {
+	// --- Given ---
+	srv := httptest.NewServer(successHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec := NewRecorder(t, dir, nil)
+
+	// --- When ---
+	rsp, err := rec.Client().Get(srv.URL)
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+
+	// --- Then ---
+	ex := NewExchange(Open(t, filepath.Join(dir, "001.yaml"), nil))
+	assert.Exactly(t, "/", ex.Request.Path)
+
+	rep := NewReplayer(t, ex)
+	_, err = rep.Client().Get("http://example.com")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,140 @@
+package golden
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Replayer is an http.RoundTripper responding to requests with responses
+// from golden file exchanges.
+//
+// For every request the first not used exchange with matching request
+// (see Request.Match) is selected and its response is returned. When all
+// matching exchanges were already used the first matching one is reused.
+//
+// Requests not matching any exchange and exchanges which were never used
+// fail the test when AssertDone is called. If the test manager implements
+// Cleanup method (like testing.T) AssertDone is registered to be called
+// when the test completes.
+type Replayer struct {
+	exs  []*Exchange // Exchanges to replay.
+	used []int       // Number of times each exchange was used.
+	errs []string    // Requests which did not match any exchange.
+	mx   sync.Mutex  // Guards used and errs.
+	t    T           // Test manager.
+}
+
+// NewReplayer returns new instance of Replayer. Every step of multi-step
+// exchange is replayed as a separate exchange, in which case exchange
+// indexes (see Used method) count the steps.
+func NewReplayer(t T, exs ...*Exchange) *Replayer {
+	t.Helper()
+
+	var steps []*Exchange
+	for _, ex := range exs {
+		if ex == nil {
+			t.Fatal(errors.New("replayer exchange needs request and response"))
+			return nil
+		}
+		for _, step := range ex.steps() {
+			if step == nil || step.Request == nil || step.Response == nil {
+				t.Fatal(errors.New("replayer exchange needs request and response"))
+				return nil
+			}
+			steps = append(steps, step)
+		}
+	}
+
+	rep := &Replayer{
+		exs:  steps,
+		used: make([]int, len(steps)),
+		t:    t,
+	}
+
+	if c, ok := t.(cleaner); ok {
+		c.Cleanup(rep.AssertDone)
+	}
+
+	return rep
+}
+
+// RoundTrip implements http.RoundTripper interface. It returns an error
+// wrapping ErrNoExchange when request does not match any exchange.
+func (rep *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	rep.mx.Lock()
+	defer rep.mx.Unlock()
+
+	idx := -1
+	for i, ex := range rep.exs {
+		if !ex.Request.Match(req) {
+			continue
+		}
+		if rep.used[i] == 0 {
+			idx = i
+			break
+		}
+		if idx == -1 {
+			idx = i
+		}
+	}
+
+	if idx == -1 {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		msg := fmt.Sprintf("%s %s", req.Method, req.URL.RequestURI())
+		rep.errs = append(rep.errs, msg)
+		return nil, fmt.Errorf("%w: %s", ErrNoExchange, msg)
+	}
+
+	rep.used[idx]++
+	rsp := rep.exs[idx].Response.Response()
+	rsp.Request = req
+	return rsp, nil
+}
+
+// Client returns HTTP client using the replayer as its transport.
+func (rep *Replayer) Client() *http.Client {
+	return &http.Client{Transport: rep}
+}
+
+// Used returns number of times exchange at index i was used.
+func (rep *Replayer) Used(i int) int {
+	rep.mx.Lock()
+	defer rep.mx.Unlock()
+	return rep.used[i]
+}
+
+// AssertDone asserts all requests matched an exchange and all exchanges
+// were used.
+func (rep *Replayer) AssertDone() {
+	rep.t.Helper()
+
+	rep.mx.Lock()
+	defer rep.mx.Unlock()
+
+	if len(rep.errs) > 0 {
+		rep.t.Fatalf(
+			"requests not matching any exchange:\n%s",
+			strings.Join(rep.errs, "\n"),
+		)
+		return
+	}
+
+	var unused []string
+	for i, ex := range rep.exs {
+		if rep.used[i] == 0 {
+			unused = append(unused, ex.Request.Method+" "+ex.Request.Path)
+		}
+	}
+	if len(unused) > 0 {
+		rep.t.Fatalf(
+			"exchanges never used:\n%s",
+			strings.Join(unused, "\n"),
+		)
+		return
+	}
+}
@@ -0,0 +1,202 @@
+package golden
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/rzajac/golden/internal"
+)
+
+// exchangeRequest returns HTTP request matching testdata/exchange.yaml
+// golden file.
+func exchangeRequest(t *testing.T) *http.Request {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		"http://example.com/some/path?key0=val0&key1=val1",
+		strings.NewReader(`{"key2": "val2"}`),
+	)
+	require.NoError(t, err)
+	req.Header.Add("Authorization", "Bearer token")
+	req.Header.Add("Content-Type", "application/json")
+	return req
+}
+
+func Test_Replayer(t *testing.T) {
+	// --- Given ---
+	ex := NewExchange(Open(t, "testdata/exchange.yaml", nil))
+	rep := NewReplayer(t, ex)
+
+	// --- When ---
+	rsp, err := rep.Client().Do(exchangeRequest(t))
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Exactly(t, 1, rep.Used(0))
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+	assert.Exactly(t, "200 OK", rsp.Status)
+	assert.Exactly(t, "application/json", rsp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	assert.Exactly(t, "{ \"success\": true }\n", string(body))
+}
+
+func Test_Replayer_NoMatchingExchange(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"requests not matching any exchange:\n%s",
+		"GET /other/path",
+	)
+
+	ex := NewExchange(Open(mck, "testdata/exchange.yaml", nil))
+	rep := NewReplayer(mck, ex)
+
+	// --- When ---
+	_, err := rep.Client().Get("http://example.com/other/path")
+
+	// --- Then ---
+	assert.True(t, errors.Is(err, ErrNoExchange))
+	rep.AssertDone()
+	mck.AssertExpectations(t)
+}
+
+func Test_Replayer_ExchangeNotUsed(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatalf", "exchanges never used:\n%s", "POST /some/path")
+
+	ex := NewExchange(Open(mck, "testdata/exchange.yaml", nil))
+	rep := NewReplayer(mck, ex)
+
+	// --- When ---
+	rep.AssertDone()
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Replayer_ExchangesUsedInOrder(t *testing.T) {
+	// --- Given ---
+	ex0 := NewExchange(Open(t, "testdata/exchange.yaml", nil))
+	ex1 := NewExchange(Open(t, "testdata/exchange.yaml", nil))
+	ex1.Response.StatusCode = http.StatusAccepted
+	rep := NewReplayer(t, ex0, ex1)
+	cli := rep.Client()
+
+	// --- When ---
+	rsp0, err0 := cli.Do(exchangeRequest(t))
+	rsp1, err1 := cli.Do(exchangeRequest(t))
+	rsp2, err2 := cli.Do(exchangeRequest(t))
+
+	// --- Then ---
+	require.NoError(t, err0)
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Exactly(t, http.StatusOK, rsp0.StatusCode)
+	assert.Exactly(t, http.StatusAccepted, rsp1.StatusCode)
+	assert.Exactly(t, http.StatusOK, rsp2.StatusCode)
+	assert.Exactly(t, 2, rep.Used(0))
+	assert.Exactly(t, 1, rep.Used(1))
+}
+
+// closeTracker is io.ReadCloser recording if it was closed.
+type closeTracker struct {
+	io.Reader
+
+	closed bool
+}
+
+// Close implements io.Closer interface.
+func (ct *closeTracker) Close() error {
+	ct.closed = true
+	return nil
+}
+
+func Test_Replayer_NoMatchingExchange_ClosesBody(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+
+	ex := NewExchange(Open(mck, "testdata/exchange.yaml", nil))
+	rep := NewReplayer(mck, ex)
+
+	body := &closeTracker{Reader: strings.NewReader("body")}
+	req, err := http.NewRequest(http.MethodPut, "http://example.com/other/path", body)
+	require.NoError(t, err)
+
+	// --- When ---
+	_, err = rep.RoundTrip(req)
+
+	// --- Then ---
+	assert.True(t, errors.Is(err, ErrNoExchange))
+	assert.True(t, body.closed)
+}
+
+func Test_NewReplayer_ExchangeWithoutRequest(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", errors.New("replayer exchange needs request and response"))
+
+	// --- When ---
+	rep := NewReplayer(mck, &Exchange{Response: &Response{}})
+
+	// --- Then ---
+	assert.Nil(t, rep)
+	mck.AssertExpectations(t)
+}
+
+func Test_Replayer_Steps(t *testing.T) {
+	// --- Given ---
+	ex := NewExchange(Open(t, "testdata/scenario.yaml", nil))
+	rep := NewReplayer(t, ex)
+	cli := rep.Client()
+
+	// --- When ---
+	rsp0, err := cli.Post("http://example.com/items", "application/json", strings.NewReader(`{"name": "item"}`))
+	require.NoError(t, err)
+	rsp1, err := cli.Get("http://example.com/items/1")
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodDelete, "http://example.com/items/1", nil)
+	require.NoError(t, err)
+	rsp2, err := cli.Do(req)
+	require.NoError(t, err)
+	rsp3, err := cli.Get("http://example.com/items/1")
+	require.NoError(t, err)
+
+	// --- Then ---
+	assert.Exactly(t, http.StatusCreated, rsp0.StatusCode)
+	assert.Exactly(t, http.StatusOK, rsp1.StatusCode)
+	assert.Exactly(t, http.StatusNoContent, rsp2.StatusCode)
+	assert.Exactly(t, http.StatusNotFound, rsp3.StatusCode)
+	for i := 0; i < 4; i++ {
+		assert.Exactly(t, 1, rep.Used(i))
+	}
+}
+
+func Test_NewReplayer_StepWithoutResponse(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", errors.New("replayer exchange needs request and response"))
+
+	ex := &Exchange{Steps: []*Exchange{{Request: &Request{}}}}
+
+	// --- When ---
+	rep := NewReplayer(mck, ex)
+
+	// --- Then ---
+	assert.Nil(t, rep)
+	mck.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package golden
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -52,6 +53,34 @@ func NewRequest(t T, r io.Reader) *Request {
 	return req
 }
 
+// RequestFrom returns new instance of Request representing HTTP request r.
+// The body type is inferred from the Content-Type header. The request body
+// is read and replaced so it can be read again. Empty URL path, like in
+// client requests to http://example.com, is represented as "/".
+func RequestFrom(t T, r *http.Request) *Request {
+	t.Helper()
+
+	req := &Request{
+		Scheme:  r.URL.Scheme,
+		Method:  r.Method,
+		Path:    urlPath(r.URL),
+		Query:   r.URL.RawQuery,
+		Headers: headers2Lines(t, r.Header),
+		t:       t,
+	}
+	if r.Body != nil {
+		body, rc := readBody(t, r.Body)
+		r.Body = rc
+		req.Body = string(body)
+	}
+	if req.Body != "" {
+		req.BodyType = bodyType(r.Header)
+	}
+	req.validate()
+
+	return req
+}
+
 // validate validates request loaded from golden file.
 func (req *Request) validate() {
 	if req.Method == "" {
@@ -71,6 +100,25 @@ func (req *Request) validate() {
 	}
 }
 
+// render returns copy of the request with references to captured values
+// in path, query, headers and body replaced with values from data.
+// See expand for details.
+func (req *Request) render(data Map) *Request {
+	req.t.Helper()
+
+	cp := *req
+	cp.Path = expand(req.Path, data)
+	cp.Query = expand(req.Query, data)
+	cp.Body = expand(req.Body, data)
+	cp.Headers = make([]string, len(req.Headers))
+	for i, ln := range req.Headers {
+		cp.Headers[i] = expand(ln, data)
+	}
+	cp.validate()
+
+	return &cp
+}
+
 // Assert asserts request matches the golden file.
 //
 // All headers defined in the golden file must match exactly but passed
@@ -87,8 +135,8 @@ func (req *Request) Assert(got *http.Request) {
 		return
 	}
 
-	if req.Path != got.URL.Path {
-		req.t.Fatalf("expected request path %s got %s", req.Path, got.URL.Path)
+	if req.Path != urlPath(got.URL) {
+		req.t.Fatalf("expected request path %s got %s", req.Path, urlPath(got.URL))
 		return
 	}
 
@@ -136,9 +184,57 @@ func (req *Request) Assert(got *http.Request) {
 	}
 }
 
+// check asserts request matches the golden file and returns an error
+// describing the mismatch instead of failing the test.
+func (req *Request) check(got *http.Request) error {
+	et := &errT{}
+	cp := *req
+	cp.t = et
+	cp.Assert(got)
+	return et.err()
+}
+
+// Match returns true if request matches the golden file. It uses the same
+// rules as Assert but does not fail the test.
+func (req *Request) Match(got *http.Request) bool {
+	if req.Method != got.Method ||
+		req.Path != urlPath(got.URL) ||
+		req.Query != got.URL.RawQuery {
+
+		return false
+	}
+
+	for key, vv := range req.headers {
+		if !reflect.DeepEqual(vv, got.Header.Values(key)) {
+			return false
+		}
+	}
+
+	var body []byte
+	if got.Body != nil {
+		var rc io.ReadCloser
+		body, rc = readBody(req.t, got.Body)
+		got.Body = rc
+	}
+
+	switch req.BodyType {
+	case TypeJSON:
+		return jsonEqual(req.Bytes(), body)
+	default:
+		return bytes.Equal(req.Bytes(), body)
+	}
+}
+
 // Request returns HTTP request represented by the golden file. It panics
 // on error.
 func (req *Request) Request() *http.Request {
+	req.t.Helper()
+	return req.RequestWithContext(context.Background())
+}
+
+// RequestWithContext returns HTTP request represented by the golden file
+// with given context. It panics on error.
+func (req *Request) RequestWithContext(ctx context.Context) *http.Request {
 	req.t.Helper()
 	httpReq := httptest.NewRequest(
 		req.Method,
@@ -147,7 +243,7 @@ func (req *Request) Request() *http.Request {
 	)
 	httpReq.URL.RawQuery = req.Query
 	httpReq.Header = lines2Headers(req.t, req.Headers...)
-	return httpReq
+	return httpReq.WithContext(ctx)
 }
 
 // Unmarshal unmarshalls request body to v based on body type. When
@@ -1,6 +1,7 @@
 package golden
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -128,6 +129,7 @@ func Test_Request_Assert_HeaderDoesNotMatch(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/some/path", nil)
 	req.URL.RawQuery = "key0=val0&key1=val1"
 	req.Header.Add("Authorization", "Bearer token2")
+	req.Header.Add("Content-Type", "application/json")
 
 	// --- When ---
 	gld := NewRequest(Open(mck, "testdata/request.yaml", nil))
@@ -215,3 +217,114 @@ func Test_Request_Bytes(t *testing.T) {
 	exp := []byte("{\n  \"key2\": \"val2\"\n}\n")
 	assert.Exactly(t, exp, gld.Bytes())
 }
+
+func Test_Request_RequestWithContext(t *testing.T) {
+	// --- Given ---
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "val")
+	gld := NewRequest(Open(t, "testdata/request.yaml", nil))
+
+	// --- When ---
+	got := gld.RequestWithContext(ctx)
+
+	// --- Then ---
+	assert.Exactly(t, http.MethodPost, got.Method)
+	assert.Exactly(t, "/some/path", got.URL.Path)
+	assert.Exactly(t, "key0=val0&key1=val1", got.URL.RawQuery)
+	assert.Exactly(t, "val", got.Context().Value(key{}))
+}
+
+func Test_RequestFrom(t *testing.T) {
+	// --- Given ---
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/some/path?key0=val0",
+		strings.NewReader(`{"key2":"val2"}`),
+	)
+	req.Header.Add("Authorization", "Bearer token")
+	req.Header.Add("Content-Type", "application/json")
+
+	// --- When ---
+	gld := RequestFrom(t, req)
+
+	// --- Then ---
+	assert.Exactly(t, http.MethodPost, gld.Method)
+	assert.Exactly(t, "/some/path", gld.Path)
+	assert.Exactly(t, "key0=val0", gld.Query)
+
+	exp := []string{
+		"Authorization: Bearer token",
+		"Content-Type: application/json",
+	}
+	assert.Exactly(t, exp, gld.Headers)
+	assert.Exactly(t, TypeJSON, gld.BodyType)
+	assert.Exactly(t, `{"key2":"val2"}`, gld.Body)
+
+	// Request body can still be read.
+	gld.Assert(req)
+}
+
+func Test_RequestFrom_noBody(t *testing.T) {
+	// --- Given ---
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/path", nil)
+	require.NoError(t, err)
+
+	// --- When ---
+	gld := RequestFrom(t, req)
+
+	// --- Then ---
+	assert.Exactly(t, "https", gld.Scheme)
+	assert.Exactly(t, http.MethodGet, gld.Method)
+	assert.Exactly(t, "/path", gld.Path)
+	assert.Exactly(t, "", gld.BodyType)
+	assert.Exactly(t, "", gld.Body)
+}
+
+func Test_RequestFrom_emptyPath(t *testing.T) {
+	// --- Given ---
+	req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	require.NoError(t, err)
+
+	// --- When ---
+	gld := RequestFrom(t, req)
+
+	// --- Then ---
+	assert.Exactly(t, "/", gld.Path)
+	assert.True(t, gld.Match(req))
+}
+
+func Test_Request_Match(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		method string
+		target string
+		auth   string
+		body   string
+		exp    bool
+	}{
+		{"match", "POST", "/some/path?key0=val0&key1=val1", "Bearer token", `{"key2":"val2"}`, true},
+		{"method", "PUT", "/some/path?key0=val0&key1=val1", "Bearer token", `{"key2":"val2"}`, false},
+		{"path", "POST", "/other/path?key0=val0&key1=val1", "Bearer token", `{"key2":"val2"}`, false},
+		{"query", "POST", "/some/path?key0=val0", "Bearer token", `{"key2":"val2"}`, false},
+		{"header", "POST", "/some/path?key0=val0&key1=val1", "Bearer other", `{"key2":"val2"}`, false},
+		{"body", "POST", "/some/path?key0=val0&key1=val1", "Bearer token", `{"key2":"val3"}`, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- Given ---
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			req.Header.Add("Authorization", tc.auth)
+			req.Header.Add("Content-Type", "application/json")
+
+			gld := NewRequest(Open(t, "testdata/request.yaml", nil))
+
+			// --- When ---
+			got := gld.Match(req)
+
+			// --- Then ---
+			assert.Exactly(t, tc.exp, got)
+		})
+	}
+}
@@ -3,24 +3,51 @@ package golden
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Response represents golden file for HTTP response.
+//
+// The statusCode field in the golden file may be a single status code (200),
+// a status code class ("2xx") or a list of codes and classes ([200, 204]).
+// When the class or the list is used it's stored in StatusCodes field and
+// StatusCode is set to zero.
+//
+// The optional status (status text, e.g. "OK" or "200 OK") and proto
+// (e.g. "HTTP/1.1", "HTTP/2.0") fields are checked only when present.
+//
+// The optional maxBodyBytes field limits the size of the response body and
+// maxDuration field (e.g. "200ms") limits the time the response takes.
+// The duration is checked only by Exchange assertions which measure it.
+//
+// The capture field maps names to capture expressions (see Captured) which
+// are evaluated against the asserted response.
 type Response struct {
-	StatusCode int                    `yaml:"statusCode"`
-	Headers    []string               `yaml:"headers"`
-	BodyType   string                 `yaml:"bodyType"`
-	Body       string                 `yaml:"body"`
-	Meta       map[string]interface{} `yaml:"meta,omitempty"`
+	StatusCode  int                    `yaml:"statusCode"`
+	StatusCodes []string               `yaml:"-"`
+	Status      string                 `yaml:"status,omitempty"`
+	Proto       string                 `yaml:"proto,omitempty"`
+	Headers     []string               `yaml:"headers"`
+	BodyType    string                 `yaml:"bodyType"`
+	Body        string                 `yaml:"body"`
+	Meta        map[string]interface{} `yaml:"meta,omitempty"`
+	Capture     map[string]string      `yaml:"capture,omitempty"`
 
-	headers http.Header // Request headers.
-	t       T           // Test manager.
+	MaxDuration  time.Duration `yaml:"maxDuration,omitempty"`
+	MaxBodyBytes int64         `yaml:"maxBodyBytes,omitempty"`
+
+	headers  http.Header // Request headers.
+	captured Map         // Values captured from asserted response.
+	t        T           // Test manager.
 }
 
 // NewResponse returns new instance of Response.
@@ -44,13 +71,46 @@ func NewResponse(t T, r io.Reader) *Response {
 	return rsp
 }
 
+// ResponseFrom returns new instance of Response representing HTTP response
+// r. The body type is inferred from the Content-Type header. The response
+// body is read and replaced so it can be read again.
+func ResponseFrom(t T, r *http.Response) *Response {
+	t.Helper()
+
+	rsp := &Response{
+		StatusCode: r.StatusCode,
+		Status:     r.Status,
+		Proto:      r.Proto,
+		Headers:    headers2Lines(t, r.Header),
+		t:          t,
+	}
+	if r.Body != nil {
+		body, rc := readBody(t, r.Body)
+		r.Body = rc
+		rsp.Body = string(body)
+	}
+	if rsp.Body != "" {
+		rsp.BodyType = bodyType(r.Header)
+	}
+	rsp.validate()
+
+	return rsp
+}
+
 // validate validates response loaded from golden file.
 func (rsp *Response) validate() {
-	if rsp.StatusCode == 0 {
+	if rsp.StatusCode == 0 && len(rsp.StatusCodes) == 0 {
 		rsp.t.Fatal(errors.New("HTTP response needs response code"))
 		return
 	}
 
+	for _, code := range rsp.StatusCodes {
+		if _, _, err := parseStatusCode(code); err != nil {
+			rsp.t.Fatal(err)
+			return
+		}
+	}
+
 	if len(rsp.Headers) > 0 {
 		rsp.headers = lines2Headers(rsp.t, rsp.Headers...)
 	} else {
@@ -65,11 +125,22 @@ func (rsp *Response) validate() {
 //
 // To compare response bodies a method best suited for body type is used.
 // For example when comparing JSON bodies both byte slices don't have to be
-// identical but they must represent the same data.
+// identical but they must represent the same data. When the golden file
+// defines captures but does not define the body the response body is not
+// compared, which allows capturing values generated by the server.
 func (rsp *Response) Assert(got *http.Response) {
 	rsp.t.Helper()
 
-	if rsp.StatusCode != got.StatusCode {
+	if len(rsp.StatusCodes) > 0 {
+		if !rsp.statusMatch(got.StatusCode) {
+			rsp.t.Fatalf(
+				"expected response status code %s got %d",
+				strings.Join(rsp.StatusCodes, ", "),
+				got.StatusCode,
+			)
+			return
+		}
+	} else if rsp.StatusCode != got.StatusCode {
 		rsp.t.Fatalf(
 			"expected response status code %d got %d",
 			rsp.StatusCode,
@@ -78,6 +149,24 @@ func (rsp *Response) Assert(got *http.Response) {
 		return
 	}
 
+	if rsp.Status != "" && !rsp.statusTextMatch(got.Status) {
+		rsp.t.Fatalf(
+			"expected response status %s got %s",
+			rsp.Status,
+			got.Status,
+		)
+		return
+	}
+
+	if rsp.Proto != "" && rsp.Proto != got.Proto {
+		rsp.t.Fatalf(
+			"expected response protocol %s got %s",
+			rsp.Proto,
+			got.Proto,
+		)
+		return
+	}
+
 	// Checks only headers set in golden file, got request may have more.
 	for key, vv := range rsp.headers {
 		g := got.Header.Values(key)
@@ -95,12 +184,27 @@ func (rsp *Response) Assert(got *http.Response) {
 	body, rc := readBody(rsp.t, got.Body)
 	defer func() { got.Body = rc }()
 
+	if rsp.MaxBodyBytes > 0 && int64(len(body)) > rsp.MaxBodyBytes {
+		rsp.t.Fatalf(
+			"expected response body size at most %d bytes got %d",
+			rsp.MaxBodyBytes,
+			len(body),
+		)
+		return
+	}
+
+	if rsp.Body == "" && len(rsp.Capture) > 0 {
+		rsp.capture(got.Header, body)
+		return
+	}
+
 	var equal bool
 	exp, have := rsp.Bytes(), body
 
 	switch rsp.BodyType {
 	case TypeJSON:
 		assertJSONEqual(rsp.t, exp, have)
+		rsp.capture(got.Header, body)
 		return
 
 	case TypeText:
@@ -117,6 +221,183 @@ func (rsp *Response) Assert(got *http.Response) {
 		)
 		return
 	}
+	rsp.capture(got.Header, body)
+}
+
+// AssertDuration asserts the response was received within the maximum
+// duration defined in the golden file. It does nothing when the maximum
+// duration is not defined.
+func (rsp *Response) AssertDuration(d time.Duration) {
+	rsp.t.Helper()
+
+	if rsp.MaxDuration > 0 && d > rsp.MaxDuration {
+		rsp.t.Fatalf(
+			"expected response duration at most %s got %s",
+			rsp.MaxDuration,
+			d,
+		)
+		return
+	}
+}
+
+// check asserts response matches the golden file and returns an error
+// describing the mismatch instead of failing the test.
+func (rsp *Response) check(got *http.Response) error {
+	et := &errT{}
+	cp := *rsp
+	cp.t = et
+	cp.Assert(got)
+	return et.err()
+}
+
+// capture evaluates capture expressions against response headers and body.
+func (rsp *Response) capture(hs http.Header, body []byte) {
+	rsp.t.Helper()
+
+	if len(rsp.Capture) == 0 {
+		return
+	}
+
+	captured := make(Map, len(rsp.Capture))
+	for name, expr := range rsp.Capture {
+		val, err := capture(expr, hs, body)
+		if err != nil {
+			rsp.t.Fatal(err)
+			return
+		}
+		captured[name] = val
+	}
+	rsp.captured = captured
+}
+
+// Captured returns values captured from the last asserted response.
+//
+// Values are captured using expressions defined in the capture field of
+// the golden file, for example:
+//
+//   capture:
+//     orderId: $.id
+//     etag: 'header:ETag'
+//
+// The returned map may be used as template data for golden files opened
+// with Open. It returns nil if nothing was captured.
+func (rsp *Response) Captured() Map {
+	return rsp.captured
+}
+
+// Response returns HTTP response represented by the golden file. When the
+// golden file defines status code classes or lists the first status code
+// in the first class or list is used.
+func (rsp *Response) Response() *http.Response {
+	code := rsp.StatusCode
+	if code == 0 && len(rsp.StatusCodes) > 0 {
+		code, _, _ = parseStatusCode(rsp.StatusCodes[0])
+	}
+
+	status := rsp.Status
+	if status == "" {
+		status = http.StatusText(code)
+	}
+	if !strings.HasPrefix(status, strconv.Itoa(code)+" ") {
+		status = strconv.Itoa(code) + " " + status
+	}
+
+	proto := rsp.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	major, minor, _ := http.ParseHTTPVersion(proto)
+
+	return &http.Response{
+		Status:        status,
+		StatusCode:    code,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        lines2Headers(rsp.t, rsp.Headers...),
+		Body:          ioutil.NopCloser(strings.NewReader(rsp.Body)),
+		ContentLength: int64(len(rsp.Body)),
+	}
+}
+
+// statusMatch returns true if code matches the status code, any of the
+// status codes or status code classes defined in the golden file.
+func (rsp *Response) statusMatch(code int) bool {
+	if rsp.StatusCode != 0 && rsp.StatusCode == code {
+		return true
+	}
+	for _, sc := range rsp.StatusCodes {
+		from, to, err := parseStatusCode(sc)
+		if err != nil {
+			continue
+		}
+		if code >= from && code <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// statusTextMatch returns true if status matches the status text defined in
+// the golden file. The status may be in "200 OK" or "OK" format.
+func (rsp *Response) statusTextMatch(status string) bool {
+	if rsp.Status == status {
+		return true
+	}
+	if i := strings.IndexByte(status, ' '); i > 0 {
+		if _, err := strconv.Atoi(status[:i]); err == nil {
+			return rsp.Status == status[i+1:]
+		}
+	}
+	return false
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface. It decodes status
+// code classes and lists of status codes to StatusCodes field.
+func (rsp *Response) UnmarshalYAML(value *yaml.Node) error {
+	type plain Response
+
+	node := *value
+	if value.Kind == yaml.MappingNode {
+		node.Content = nil
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, val := value.Content[i], value.Content[i+1]
+			if key.Value == "statusCode" && val.ShortTag() != "!!int" &&
+				val.ShortTag() != "!!null" {
+
+				codes, err := decodeStatusCodes(val)
+				if err != nil {
+					return err
+				}
+				rsp.StatusCodes = codes
+				continue
+			}
+			node.Content = append(node.Content, key, val)
+		}
+	}
+
+	return node.Decode((*plain)(rsp))
+}
+
+// MarshalYAML implements yaml.Marshaler interface. It encodes StatusCodes
+// field as the statusCode field when it is set.
+func (rsp *Response) MarshalYAML() (interface{}, error) {
+	type plain Response
+
+	if len(rsp.StatusCodes) == 0 {
+		return (*plain)(rsp), nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode((*plain)(rsp)); err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "statusCode" {
+			node.Content[i+1] = encodeStatusCodes(rsp.StatusCodes)
+		}
+	}
+	return node, nil
 }
 
 // Unmarshal unmarshalls response body to v based on BodyType. Calls Fatal
@@ -134,3 +415,72 @@ func (rsp *Response) Unmarshal(v interface{}) {
 func (rsp *Response) Bytes() []byte {
 	return []byte(rsp.Body)
 }
+
+// decodeStatusCodes decodes YAML node representing status code class or
+// a list of status codes and status code classes.
+func decodeStatusCodes(node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{strings.ToLower(node.Value)}, nil
+
+	case yaml.SequenceNode:
+		codes := make([]string, 0, len(node.Content))
+		for _, n := range node.Content {
+			if n.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf(
+					"invalid HTTP response status code on line %d",
+					n.Line,
+				)
+			}
+			codes = append(codes, strings.ToLower(n.Value))
+		}
+		return codes, nil
+
+	default:
+		return nil, fmt.Errorf(
+			"invalid HTTP response status code on line %d",
+			node.Line,
+		)
+	}
+}
+
+// encodeStatusCodes returns YAML node representing list of status codes
+// and status code classes.
+func encodeStatusCodes(codes []string) *yaml.Node {
+	nodes := make([]*yaml.Node, 0, len(codes))
+	for _, code := range codes {
+		n := &yaml.Node{Kind: yaml.ScalarNode, Value: code, Tag: "!!str"}
+		if _, err := strconv.Atoi(code); err == nil {
+			n.Tag = "!!int"
+		}
+		nodes = append(nodes, n)
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+
+	return &yaml.Node{
+		Kind:    yaml.SequenceNode,
+		Style:   yaml.FlowStyle,
+		Tag:     "!!seq",
+		Content: nodes,
+	}
+}
+
+// parseStatusCode parses status code (200) or status code class (2xx) and
+// returns the range of status codes it represents.
+func parseStatusCode(code string) (int, int, error) {
+	if len(code) == 3 && strings.HasSuffix(code, "xx") {
+		class := int(code[0] - '0')
+		if class >= 1 && class <= 5 {
+			return class * 100, class*100 + 99, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(code); err == nil && n >= 100 && n <= 599 {
+		return n, n, nil
+	}
+
+	return 0, 0, fmt.Errorf("invalid HTTP response status code: %s", code)
+}
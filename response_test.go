@@ -1,6 +1,8 @@
 package golden
 
 import (
+	"bytes"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -10,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	. "github.com/rzajac/golden/internal"
 )
@@ -147,3 +150,352 @@ func Test_Response_Bytes(t *testing.T) {
 	exp := []byte("{ \"key2\": \"val2\" }\n")
 	assert.Exactly(t, exp, gld.Bytes())
 }
+
+func Test_Response_StatusCodeClass(t *testing.T) {
+	// --- When ---
+	gld := NewResponse(Open(t, "testdata/response_class.yaml", nil))
+
+	// --- Then ---
+	assert.Exactly(t, 0, gld.StatusCode)
+	assert.Exactly(t, []string{"2xx"}, gld.StatusCodes)
+	assert.Exactly(t, "{ \"key2\": \"val2\" }\n", gld.Body)
+}
+
+func Test_Response_StatusCodeList(t *testing.T) {
+	// --- When ---
+	gld := NewResponse(Open(t, "testdata/response_codes.yaml", nil))
+
+	// --- Then ---
+	assert.Exactly(t, 0, gld.StatusCode)
+	assert.Exactly(t, []string{"200", "204", "3xx"}, gld.StatusCodes)
+}
+
+func Test_Response_StatusCode_invalid(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", mock.AnythingOfType("*errors.errorString"))
+
+	// --- When ---
+	NewResponse(mck, strings.NewReader("statusCode: 6xx\n"))
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Response_Assert_StatusCodes(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		pth  string
+		code int
+	}{
+		{"class", "testdata/response_class.yaml", 201},
+		{"list code", "testdata/response_codes.yaml", 204},
+		{"list class", "testdata/response_codes.yaml", 301},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- Given ---
+			rsp := &http.Response{
+				StatusCode: tc.code,
+				Header:     make(http.Header),
+				Body:       ioutil.NopCloser(strings.NewReader(`{"key2":"val2"}`)),
+			}
+			rsp.Header.Add("Content-Type", "application/json")
+
+			gld := NewResponse(Open(t, tc.pth, nil))
+
+			// --- Then ---
+			gld.Assert(rsp)
+		})
+	}
+}
+
+func Test_Response_Assert_StatusCodesDoNotMatch(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"expected response status code %s got %d",
+		"200, 204, 3xx",
+		201,
+	)
+
+	rsp := &http.Response{
+		StatusCode: 201,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(`{"key2":"val2"}`)),
+	}
+
+	gld := NewResponse(Open(mck, "testdata/response_codes.yaml", nil))
+
+	// --- When ---
+	gld.Assert(rsp)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Response_MarshalYAML_StatusCodes(t *testing.T) {
+	// --- Given ---
+	gld := NewResponse(Open(t, "testdata/response_codes.yaml", nil))
+
+	// --- When ---
+	data, err := yaml.Marshal(gld)
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "statusCode: [200, 204, 3xx]\n")
+
+	got := NewResponse(t, bytes.NewReader(data))
+	assert.Exactly(t, []string{"200", "204", "3xx"}, got.StatusCodes)
+}
+
+func Test_Response_StatusAndProto(t *testing.T) {
+	// --- When ---
+	gld := NewResponse(Open(t, "testdata/response_proto.yaml", nil))
+
+	// --- Then ---
+	assert.Exactly(t, 200, gld.StatusCode)
+	assert.Exactly(t, "OK", gld.Status)
+	assert.Exactly(t, "HTTP/2.0", gld.Proto)
+}
+
+func Test_Response_Assert_StatusAndProto(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		status string
+	}{
+		{"status text", "OK"},
+		{"status line", "200 OK"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- Given ---
+			rsp := &http.Response{
+				Status:     tc.status,
+				StatusCode: 200,
+				Proto:      "HTTP/2.0",
+				Header:     make(http.Header),
+				Body:       ioutil.NopCloser(strings.NewReader(`{"key2":"val2"}`)),
+			}
+			rsp.Header.Add("Content-Type", "application/json")
+
+			gld := NewResponse(Open(t, "testdata/response_proto.yaml", nil))
+
+			// --- Then ---
+			gld.Assert(rsp)
+		})
+	}
+}
+
+func Test_Response_Assert_StatusDoesNotMatch(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatalf", "expected response status %s got %s", "OK", "200 Fine")
+
+	rsp := &http.Response{
+		Status:     "200 Fine",
+		StatusCode: 200,
+		Proto:      "HTTP/2.0",
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(`{"key2":"val2"}`)),
+	}
+
+	gld := NewResponse(Open(mck, "testdata/response_proto.yaml", nil))
+
+	// --- When ---
+	gld.Assert(rsp)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Response_Assert_ProtoDoesNotMatch(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"expected response protocol %s got %s",
+		"HTTP/2.0",
+		"HTTP/1.1",
+	)
+
+	rsp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Proto:      "HTTP/1.1",
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(`{"key2":"val2"}`)),
+	}
+
+	gld := NewResponse(Open(mck, "testdata/response_proto.yaml", nil))
+
+	// --- When ---
+	gld.Assert(rsp)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Response_MarshalYAML_StatusAndProto(t *testing.T) {
+	// --- Given ---
+	gld := NewResponse(Open(t, "testdata/response_proto.yaml", nil))
+
+	// --- When ---
+	data, err := yaml.Marshal(gld)
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "status: OK\nproto: HTTP/2.0\n")
+}
+
+func Test_ResponseFrom(t *testing.T) {
+	// --- Given ---
+	rsp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Proto:      "HTTP/1.1",
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(`{"key2":"val2"}`)),
+	}
+	rsp.Header.Add("Content-Type", "application/json; charset=utf-8")
+
+	// --- When ---
+	gld := ResponseFrom(t, rsp)
+
+	// --- Then ---
+	assert.Exactly(t, 200, gld.StatusCode)
+	assert.Exactly(t, "200 OK", gld.Status)
+	assert.Exactly(t, "HTTP/1.1", gld.Proto)
+
+	exp := []string{"Content-Type: application/json; charset=utf-8"}
+	assert.Exactly(t, exp, gld.Headers)
+	assert.Exactly(t, TypeJSON, gld.BodyType)
+	assert.Exactly(t, `{"key2":"val2"}`, gld.Body)
+
+	// Response body can still be read.
+	gld.Assert(rsp)
+}
+
+func Test_Response_Response(t *testing.T) {
+	// --- Given ---
+	gld := NewResponse(Open(t, "testdata/response.yaml", nil))
+
+	// --- When ---
+	got := gld.Response()
+
+	// --- Then ---
+	assert.Exactly(t, 200, got.StatusCode)
+	assert.Exactly(t, "200 OK", got.Status)
+	assert.Exactly(t, "HTTP/1.1", got.Proto)
+	assert.Exactly(t, "Bearer token", got.Header.Get("Authorization"))
+	assert.Exactly(t, "application/json", got.Header.Get("Content-Type"))
+
+	gld.Assert(got)
+}
+
+func Test_Response_Response_StatusCodes(t *testing.T) {
+	// --- Given ---
+	gld := NewResponse(Open(t, "testdata/response_class.yaml", nil))
+
+	// --- When ---
+	got := gld.Response()
+
+	// --- Then ---
+	assert.Exactly(t, 200, got.StatusCode)
+	assert.Exactly(t, "200 OK", got.Status)
+}
+
+func Test_Response_Captured(t *testing.T) {
+	// --- Given ---
+	rsp := &http.Response{
+		StatusCode: 200,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(`{"id":"123","name":"item"}`)),
+	}
+	rsp.Header.Add("ETag", `"v1"`)
+
+	gld := NewResponse(Open(t, "testdata/response_capture.yaml", nil))
+
+	// --- When ---
+	gld.Assert(rsp)
+
+	// --- Then ---
+	exp := Map{"itemId": "123", "etag": `"v1"`}
+	assert.Exactly(t, exp, gld.Captured())
+}
+
+func Test_Response_Captured_NoBody(t *testing.T) {
+	// --- Given ---
+	rsp := &http.Response{
+		StatusCode: 201,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(`{"id":"generated"}`)),
+	}
+
+	gld := NewResponse(Open(t, "testdata/response_capture_nobody.yaml", nil))
+
+	// --- When ---
+	gld.Assert(rsp)
+
+	// --- Then ---
+	assert.Exactly(t, Map{"itemId": "generated"}, gld.Captured())
+}
+
+func Test_Response_Assert_NoBody(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"expected response body to match want\n %s\ngot\n%s",
+		"",
+		[]byte("unexpected body"),
+	)
+
+	rsp := &http.Response{
+		StatusCode: 204,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader("unexpected body")),
+	}
+
+	gld := NewResponse(mck, strings.NewReader("statusCode: 204\n"))
+
+	// --- When ---
+	gld.Assert(rsp)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Response_Captured_Error(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		return errors.Is(err, ErrNoCapture)
+	}))
+
+	rsp := &http.Response{
+		StatusCode: 200,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(`{"id":"123","name":"item"}`)),
+	}
+
+	gld := NewResponse(Open(mck, "testdata/response_capture.yaml", nil))
+
+	// --- When ---
+	gld.Assert(rsp)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+	assert.Nil(t, gld.Captured())
+}
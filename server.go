@@ -0,0 +1,188 @@
+package golden
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is a HTTP test server responding to requests with responses from
+// golden file exchanges.
+//
+// For every request the exchanges with the same request method and path
+// are considered. The first not used exchange matching the request (see
+// Request.Match) is selected, if there is none the first matching (already
+// used) exchange or the first exchange with the same method and path is
+// selected. The request is then verified with Request.Assert and the
+// exchange response is sent back. Requests failing verification are
+// responded with 500 Internal Server Error status code and requests not
+// matching any exchange with 404 Not Found status code.
+//
+// Requests not matching any exchange, failed verifications and exchanges
+// which were never used fail the test when AssertDone is called. If the
+// test manager implements Cleanup method (like testing.T) the server is
+// closed and AssertDone is called when the test completes.
+type Server struct {
+	*httptest.Server
+
+	pths  []string    // Golden file path of each exchange.
+	names []string    // Exchange names used in failure messages.
+	exs   []*Exchange // Exchanges loaded from golden files.
+	calls []int       // Number of times each exchange was used.
+	errs  []string    // Requests not matching any exchange.
+	fails []string    // Request verification failures.
+	mx    sync.Mutex  // Guards calls, errs and fails.
+	t     T           // Test manager.
+}
+
+// NewServer starts and returns new instance of Server responding with
+// exchanges from golden files pointed by pths. Every step of multi-step
+// exchange is served as a separate exchange.
+func NewServer(t T, pths ...string) *Server {
+	t.Helper()
+
+	srv := &Server{t: t}
+
+	for _, pth := range pths {
+		ex := NewExchange(Open(t, pth, nil))
+		if ex == nil {
+			return nil
+		}
+		if len(ex.Steps) == 0 && (ex.Request == nil || ex.Response == nil) {
+			t.Fatal(fmt.Errorf("%s: server exchange needs request and response", pth))
+			return nil
+		}
+		for i, step := range ex.steps() {
+			name := pth
+			if len(ex.Steps) > 0 {
+				name = fmt.Sprintf("%s step %d", pth, i+1)
+			}
+			srv.pths = append(srv.pths, pth)
+			srv.names = append(srv.names, name)
+			srv.exs = append(srv.exs, step)
+		}
+	}
+	srv.calls = make([]int, len(srv.exs))
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.serveHTTP))
+
+	if c, ok := t.(cleaner); ok {
+		c.Cleanup(func() {
+			srv.Close()
+			srv.AssertDone()
+		})
+	}
+
+	return srv
+}
+
+// serveHTTP responds to the request with matching exchange response.
+func (srv *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mx.Lock()
+	defer srv.mx.Unlock()
+
+	idx := srv.find(r)
+	if idx == -1 {
+		msg := fmt.Sprintf("no exchange for %s %s", r.Method, r.URL.RequestURI())
+		srv.errs = append(srv.errs, msg)
+		http.Error(w, msg, http.StatusNotFound)
+		return
+	}
+
+	srv.calls[idx]++
+	if err := srv.exs[idx].Request.check(r); err != nil {
+		msg := fmt.Sprintf("%s: %s", srv.names[idx], err)
+		srv.fails = append(srv.fails, msg)
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+
+	rsp := srv.exs[idx].Response.Response()
+	for key, vv := range rsp.Header {
+		w.Header()[key] = vv
+	}
+	w.WriteHeader(rsp.StatusCode)
+	_, _ = w.Write(srv.exs[idx].Response.Bytes())
+}
+
+// find returns index of the exchange to respond with or -1 if there
+// is no exchange with the same method and path as r.
+func (srv *Server) find(r *http.Request) int {
+	route, match := -1, -1
+	for i, ex := range srv.exs {
+		if ex.Request.Method != r.Method || ex.Request.Path != r.URL.Path {
+			continue
+		}
+		if route == -1 {
+			route = i
+		}
+		if !ex.Request.Match(r) {
+			continue
+		}
+		if srv.calls[i] == 0 {
+			return i
+		}
+		if match == -1 {
+			match = i
+		}
+	}
+	if match != -1 {
+		return match
+	}
+	return route
+}
+
+// Calls returns number of times exchange from golden file pth was used.
+func (srv *Server) Calls(pth string) int {
+	srv.mx.Lock()
+	defer srv.mx.Unlock()
+
+	var cnt int
+	for i, p := range srv.pths {
+		if p == pth {
+			cnt += srv.calls[i]
+		}
+	}
+	return cnt
+}
+
+// AssertDone asserts all requests matched an exchange, were verified
+// successfully and all exchanges were used.
+func (srv *Server) AssertDone() {
+	srv.t.Helper()
+
+	srv.mx.Lock()
+	defer srv.mx.Unlock()
+
+	if len(srv.errs) > 0 {
+		srv.t.Fatalf(
+			"requests not matching any exchange:\n%s",
+			strings.Join(srv.errs, "\n"),
+		)
+		return
+	}
+
+	if len(srv.fails) > 0 {
+		srv.t.Fatalf(
+			"requests failing verification:\n%s",
+			strings.Join(srv.fails, "\n"),
+		)
+		return
+	}
+
+	var unused []string
+	for i, name := range srv.names {
+		if srv.calls[i] == 0 {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		srv.t.Fatalf(
+			"exchanges never used:\n%s",
+			strings.Join(unused, "\n"),
+		)
+		return
+	}
+}
@@ -0,0 +1,177 @@
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/rzajac/golden/internal"
+)
+
+func Test_Server(t *testing.T) {
+	// --- Given ---
+	srv := NewServer(t, "testdata/exchange.yaml")
+	req := exchangeRequest(t)
+	req.URL.Host = srv.Listener.Addr().String()
+
+	// --- When ---
+	rsp, err := srv.Client().Do(req)
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Exactly(t, 1, srv.Calls("testdata/exchange.yaml"))
+	assert.Exactly(t, http.StatusOK, rsp.StatusCode)
+	assert.Exactly(t, "application/json", rsp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	assert.Exactly(t, "{ \"success\": true }\n", string(body))
+}
+
+func Test_Server_RequestDoesNotMatch(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"requests failing verification:\n%s",
+		mock.MatchedBy(func(msg string) bool {
+			return strings.Contains(msg, "expected request header")
+		}),
+	)
+
+	srv := NewServer(mck, "testdata/exchange.yaml")
+	defer srv.Close()
+
+	req := exchangeRequest(t)
+	req.URL.Host = srv.Listener.Addr().String()
+	req.Header.Set("Authorization", "Bearer other")
+
+	// --- When ---
+	rsp, err := srv.Client().Do(req)
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Exactly(t, http.StatusInternalServerError, rsp.StatusCode)
+	srv.AssertDone()
+	mck.AssertExpectations(t)
+}
+
+func Test_Server_NoExchange(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"requests not matching any exchange:\n%s",
+		"no exchange for GET /other/path",
+	)
+
+	srv := NewServer(mck, "testdata/exchange.yaml")
+	defer srv.Close()
+
+	// --- When ---
+	rsp, err := srv.Client().Get(srv.URL + "/other/path")
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Exactly(t, http.StatusNotFound, rsp.StatusCode)
+	srv.AssertDone()
+	mck.AssertExpectations(t)
+}
+
+func Test_Server_ExchangeNotUsed(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"exchanges never used:\n%s",
+		"testdata/exchange.yaml",
+	)
+
+	srv := NewServer(mck, "testdata/exchange.yaml")
+	defer srv.Close()
+
+	// --- When ---
+	srv.AssertDone()
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_NewServer_ExchangeWithoutResponse(t *testing.T) {
+	// --- Given ---
+	pth := filepath.Join(t.TempDir(), "exchange.yaml")
+	content := "request:\n  method: GET\n  path: /some/path\n"
+	require.NoError(t, ioutil.WriteFile(pth, []byte(content), 0600))
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", fmt.Errorf("%s: server exchange needs request and response", pth))
+
+	// --- When ---
+	srv := NewServer(mck, pth)
+
+	// --- Then ---
+	assert.Nil(t, srv)
+	mck.AssertExpectations(t)
+}
+
+func Test_Server_Steps(t *testing.T) {
+	// --- Given ---
+	srv := NewServer(t, "testdata/scenario.yaml")
+	cli := srv.Client()
+
+	// --- When ---
+	rsp0, err := cli.Post(srv.URL+"/items", "application/json", strings.NewReader(`{"name": "item"}`))
+	require.NoError(t, err)
+	rsp1, err := cli.Get(srv.URL + "/items/1")
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodDelete, srv.URL+"/items/1", nil)
+	require.NoError(t, err)
+	rsp2, err := cli.Do(req)
+	require.NoError(t, err)
+	rsp3, err := cli.Get(srv.URL + "/items/1")
+	require.NoError(t, err)
+
+	// --- Then ---
+	assert.Exactly(t, http.StatusCreated, rsp0.StatusCode)
+	assert.Exactly(t, http.StatusOK, rsp1.StatusCode)
+	assert.Exactly(t, http.StatusNoContent, rsp2.StatusCode)
+	assert.Exactly(t, http.StatusNotFound, rsp3.StatusCode)
+	assert.Exactly(t, 4, srv.Calls("testdata/scenario.yaml"))
+}
+
+func Test_Server_StepNotUsed(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"exchanges never used:\n%s",
+		"testdata/scenario.yaml step 3\ntestdata/scenario.yaml step 4",
+	)
+
+	srv := NewServer(mck, "testdata/scenario.yaml")
+	defer srv.Close()
+	cli := srv.Client()
+
+	_, err := cli.Post(srv.URL+"/items", "application/json", strings.NewReader(`{"name": "item"}`))
+	require.NoError(t, err)
+	_, err = cli.Get(srv.URL + "/items/1")
+	require.NoError(t, err)
+
+	// --- When ---
+	srv.AssertDone()
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
@@ -0,0 +1,99 @@
+package golden
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Snapshot serializes v to JSON and compares it with the golden file
+// pointed by ForTest. The test fails when the golden file does not exist.
+//
+// In update mode, turned on with the GOLDEN_UPDATE=1 environment variable
+// (see EnvUpdate), the golden file is written, or overwritten, instead of
+// being compared with and the path of the written file is logged, if t
+// implements Logf method (like testing.T does):
+//
+//   GOLDEN_UPDATE=1 go test ./...
+//
+// The JSON representation is deterministic, map keys are sorted and struct
+// fields are written in their declaration order. The test manager t must
+// have the Name method (like *testing.T does).
+func Snapshot(t T, v interface{}) {
+	t.Helper()
+
+	nt, ok := t.(namer)
+	if !ok {
+		t.Fatal(errors.New("snapshot needs test manager with Name method"))
+		return
+	}
+
+	snapshot(t, ForTest(nt), v)
+}
+
+// snapshot serializes v to JSON and compares it with the golden file
+// pointed by pth. In update mode it writes the golden file instead.
+func snapshot(t T, pth string, v interface{}) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if updateMode() {
+		if err := writeSnapshot(pth, data); err != nil {
+			t.Fatal(err)
+			return
+		}
+		if l, ok := t.(logger); ok {
+			l.Logf("golden file %s written", pth)
+		}
+		return
+	}
+
+	if _, err := os.Stat(pth); errors.Is(err, os.ErrNotExist) {
+		t.Fatalf(
+			"golden file %s does not exist, run tests with %s=1 to create it",
+			pth,
+			EnvUpdate,
+		)
+		return
+	}
+
+	fil := New(Open(t, pth, nil))
+	if fil == nil {
+		return
+	}
+	fil.Assert(data)
+}
+
+// updateMode returns true if update mode is turned on with EnvUpdate
+// environment variable.
+func updateMode() bool {
+	val := os.Getenv(EnvUpdate)
+	return val == "1" || strings.EqualFold(val, "true")
+}
+
+// writeSnapshot writes golden file with JSON data to pth creating missing
+// directories.
+func writeSnapshot(pth string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		return err
+	}
+
+	fh, err := os.Create(pth)
+	if err != nil {
+		return err
+	}
+
+	fil := &File{BodyType: TypeJSON, Body: string(data) + "\n"}
+	if _, err := fil.WriteTo(fh); err != nil {
+		_ = fh.Close()
+		return err
+	}
+	return fh.Close()
+}
@@ -0,0 +1,167 @@
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/rzajac/golden/internal"
+)
+
+// snapshotData represents value serialized in snapshot tests.
+type snapshotData struct {
+	Name  string            `json:"name"`
+	Count int               `json:"count"`
+	Tags  map[string]string `json:"tags"`
+}
+
+func Test_Snapshot(t *testing.T) {
+	// --- Given ---
+	v := snapshotData{
+		Name:  "name",
+		Count: 2,
+		Tags:  map[string]string{"b": "2", "a": "1", "c": "3"},
+	}
+
+	// --- Then ---
+	Snapshot(t, v)
+}
+
+func Test_Snapshot_notNamed(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		return err.Error() == "snapshot needs test manager with Name method"
+	}))
+
+	// --- When ---
+	Snapshot(mck, Map{})
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_snapshot_update(t *testing.T) {
+	// --- Given ---
+	t.Setenv(EnvUpdate, "1")
+	pth := filepath.Join(t.TempDir(), "dir", "snapshot.yaml")
+	v := Map{"b": 2, "a": Map{"d": 4, "c": 3}}
+
+	mck := &TMock{}
+	mck.On("Helper")
+
+	// --- When ---
+	snapshot(mck, pth, v)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+
+	data, err := ioutil.ReadFile(pth)
+	require.NoError(t, err)
+	exp := strings.Join([]string{
+		"bodyType: json",
+		"body: |",
+		"    {",
+		`      "a": {`,
+		`        "c": 3,`,
+		`        "d": 4`,
+		"      },",
+		`      "b": 2`,
+		"    }",
+		"",
+	}, "\n")
+	assert.Exactly(t, exp, string(data))
+
+	t.Setenv(EnvUpdate, "")
+	snapshot(t, pth, v)
+}
+
+func Test_snapshot_update_overwrites(t *testing.T) {
+	// --- Given ---
+	pth := filepath.Join(t.TempDir(), "snapshot.yaml")
+	t.Setenv(EnvUpdate, "true")
+	snapshot(t, pth, Map{"a": 1})
+
+	// --- When ---
+	snapshot(t, pth, Map{"a": 2})
+
+	// --- Then ---
+	t.Setenv(EnvUpdate, "")
+	snapshot(t, pth, Map{"a": 2})
+}
+
+func Test_snapshot_update_logs(t *testing.T) {
+	// --- Given ---
+	t.Setenv(EnvUpdate, "1")
+	pth := filepath.Join(t.TempDir(), "snapshot.yaml")
+	lt := &logT{TMock: &TMock{}}
+	lt.On("Helper")
+
+	// --- When ---
+	snapshot(lt, pth, Map{"a": 1})
+
+	// --- Then ---
+	assert.Exactly(t, []string{"golden file " + pth + " written"}, lt.logs)
+}
+
+func Test_snapshot_missing(t *testing.T) {
+	// --- Given ---
+	t.Setenv(EnvUpdate, "")
+	pth := filepath.Join(t.TempDir(), "snapshot.yaml")
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On(
+		"Fatalf",
+		"golden file %s does not exist, run tests with %s=1 to create it",
+		pth,
+		EnvUpdate,
+	)
+
+	// --- When ---
+	snapshot(mck, pth, Map{"a": 1})
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+	_, err := ioutil.ReadFile(pth)
+	assert.Error(t, err)
+}
+
+func Test_snapshot_mismatch(t *testing.T) {
+	// --- Given ---
+	pth := filepath.Join(t.TempDir(), "snapshot.yaml")
+	t.Setenv(EnvUpdate, "1")
+	snapshot(t, pth, Map{"a": 1})
+	t.Setenv(EnvUpdate, "")
+
+	mck := &TMock{}
+	mck.On("Helper")
+	mck.On("Fatal", mock.MatchedBy(func(msg string) bool {
+		return strings.HasPrefix(msg, "Not equal:")
+	}))
+
+	// --- When ---
+	snapshot(mck, pth, Map{"a": 2})
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+// logT is a test manager mock recording logged messages.
+type logT struct {
+	*TMock
+
+	logs []string
+}
+
+// Logf records formatted message.
+func (lt *logT) Logf(format string, args ...interface{}) {
+	lt.logs = append(lt.logs, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,159 @@
+package golden
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FuncMap returns functions available in golden file templates:
+//
+//   env "NAME"               - value of environment variable,
+//   default "def" .val       - .val or "def" if .val is empty,
+//   now                      - current time,
+//   date "2006-01-02" .time  - time formatted with layout,
+//   uuid                     - random (version 4) UUID,
+//   base64 .val              - base64 encoded string,
+//   json .val                - value marshalled to JSON,
+//   toYaml .val              - value marshalled to YAML,
+//   indent 4 .val            - string with every line indented.
+//
+// Additionally, the include function is available in templates executed
+// by Open.
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"env":     os.Getenv,
+		"default": tplDefault,
+		"now":     time.Now,
+		"date":    tplDate,
+		"uuid":    tplUUID,
+		"base64":  tplBase64,
+		"json":    tplJSON,
+		"toYaml":  tplYAML,
+		"indent":  tplIndent,
+	}
+}
+
+// execute executes golden file pth content as a template with data.
+// Files included with the include template function are read with the
+// loader, so include cycles are detected the same way as for !include tags.
+func (ld *loader) execute(pth string, content []byte, data interface{}) ([]byte, error) {
+	include := func(name string, data interface{}) (string, error) {
+		ipth := ld.rel(pth, name)
+		if err := ld.push(ipth); err != nil {
+			return "", err
+		}
+		defer ld.pop()
+
+		content, err := ld.read(ipth)
+		if err != nil {
+			return "", err
+		}
+		content, err = ld.execute(ipth, content, data)
+		return string(content), err
+	}
+
+	// Template is named after the golden file, so errors report its path.
+	tpl := template.New(pth).
+		Funcs(FuncMap()).
+		Funcs(template.FuncMap{"include": include})
+
+	for _, opt := range ld.opts {
+		tpl = opt(tpl)
+	}
+
+	tpl, err := tpl.Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFields(tpl, data); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tplDefault returns val or def if val is empty.
+func tplDefault(def, val interface{}) interface{} {
+	if val == nil {
+		return def
+	}
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if v.Len() == 0 {
+			return def
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return def
+		}
+	default:
+		if v.IsZero() {
+			return def
+		}
+	}
+	return val
+}
+
+// tplDate returns time formatted according to layout.
+func tplDate(layout string, tim time.Time) string {
+	return tim.Format(layout)
+}
+
+// tplUUID returns random (version 4) UUID.
+func tplUUID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
+
+// tplBase64 returns base64 encoded string representation of val.
+func tplBase64(val interface{}) string {
+	switch v := val.(type) {
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	default:
+		return base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(v)))
+	}
+}
+
+// tplJSON returns val marshalled to JSON.
+func tplJSON(val interface{}) (string, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// tplYAML returns val marshalled to YAML without trailing new line.
+func tplYAML(val interface{}) (string, error) {
+	data, err := yaml.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// tplIndent returns s with every line indented with n spaces.
+func tplIndent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
@@ -0,0 +1,340 @@
+package golden
+
+import (
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/rzajac/golden/internal"
+)
+
+func Test_Open_FuncMap(t *testing.T) {
+	// --- Given ---
+	data := Map{
+		"token": "tok",
+		"key1":  "val1",
+		"body":  Map{"key2": "val2"},
+	}
+
+	// --- When ---
+	gld := NewRequest(Open(t, "testdata/request_funcs.tpl.yaml", data))
+
+	// --- Then ---
+	assert.Exactly(t, "key0=val0&key1=dmFsMQ==", gld.Query)
+
+	exp := []string{
+		"Authorization: Bearer tok",
+		"Content-Type: application/json",
+	}
+	assert.Exactly(t, exp, gld.Headers)
+	assert.Exactly(t, "{\"key2\":\"val2\"}\n", gld.Body)
+}
+
+func Test_FuncMap(t *testing.T) {
+	// --- Given ---
+	require.NoError(t, os.Setenv("GOLDEN_TEST_ENV", "env value"))
+	defer func() { _ = os.Unsetenv("GOLDEN_TEST_ENV") }()
+
+	tt := []struct {
+		testN string
+
+		tpl  string
+		data interface{}
+		exp  string
+	}{
+		{"env", `{{ env "GOLDEN_TEST_ENV" }}`, nil, "env value"},
+		{"default empty", `{{ default "def" .val }}`, Map{"val": ""}, "def"},
+		{"default missing", `{{ default "def" .val }}`, Map{}, "def"},
+		{"default zero", `{{ default 1 .val }}`, Map{"val": 0}, "1"},
+		{"default set", `{{ .val | default "def" }}`, Map{"val": "val"}, "val"},
+		{"date", `{{ date "2006-01-02" .tim }}`, Map{"tim": time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)}, "2021-02-28"},
+		{"base64", `{{ base64 .val }}`, Map{"val": "val"}, "dmFs"},
+		{"json", `{{ json .val }}`, Map{"val": []int{1, 2}}, "[1,2]"},
+		{"toYaml", `{{ toYaml .val }}`, Map{"val": Map{"key": "val"}}, "key: val"},
+		{"indent", `{{ indent 2 .val }}`, Map{"val": "a\nb"}, "  a\n  b"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- When ---
+			got, err := (&loader{}).execute("golden.yaml", []byte(tc.tpl), tc.data)
+
+			// --- Then ---
+			require.NoError(t, err)
+			assert.Exactly(t, tc.exp, string(got))
+		})
+	}
+}
+
+func Test_FuncMap_now(t *testing.T) {
+	// --- When ---
+	got, err := (&loader{}).execute("golden.yaml", []byte(`{{ now | date "2006" }}`), nil)
+
+	// --- Then ---
+	require.NoError(t, err)
+	assert.Exactly(t, time.Now().Format("2006"), string(got))
+}
+
+func Test_FuncMap_uuid(t *testing.T) {
+	// --- When ---
+	got, err := (&loader{}).execute("golden.yaml", []byte(`{{ uuid }}`), nil)
+
+	// --- Then ---
+	require.NoError(t, err)
+	exp := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Regexp(t, exp, string(got))
+}
+
+func Test_FuncMap_base64Bytes(t *testing.T) {
+	// --- When ---
+	got := tplBase64([]byte{0, 1, 2})
+
+	// --- Then ---
+	assert.Exactly(t, base64.StdEncoding.EncodeToString([]byte{0, 1, 2}), got)
+}
+
+func Test_Open_TplFuncs(t *testing.T) {
+	// --- Given ---
+	src := "bodyType: text\nbody: {{ sign .val }} {{ uuid }}\n"
+	pth := filepath.Join(t.TempDir(), "file.yaml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte(src), 0600))
+
+	funcs := template.FuncMap{
+		"sign": func(s string) string { return "signed-" + s },
+		"uuid": func() string { return "uuid" },
+	}
+
+	// --- When ---
+	gld := New(Open(t, pth, Map{"val": "val"}, TplFuncs(funcs)))
+
+	// --- Then ---
+	assert.Exactly(t, "signed-val uuid", gld.Body)
+}
+
+func Test_Open_TplOption(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Fatal", mock.AnythingOfType("template.ExecError"))
+
+	// --- When ---
+	_, r := Open(
+		mck,
+		"testdata/file_metadata.yaml",
+		Map{"other": "val"},
+		TplOption("missingkey=error"),
+	)
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+	assert.Nil(t, r)
+}
+
+func Test_Open_TplStrict(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		exp := `template: testdata/request_strict.tpl.yaml:5:30: ` +
+			`executing "testdata/request_strict.tpl.yaml" at <.tokne>: ` +
+			`map has no entry for key "tokne"`
+		return err.Error() == exp
+	}))
+
+	// --- When ---
+	Open(mck, "testdata/request_strict.tpl.yaml", Map{"token": "tok"}, TplStrict())
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_Open_notStrict(t *testing.T) {
+	// --- When ---
+	gld := NewRequest(Open(t, "testdata/request_strict.tpl.yaml", Map{"token": "tok"}))
+
+	// --- Then ---
+	assert.Exactly(t, []string{"Authorization: Bearer <no value>"}, gld.Headers)
+}
+
+// tplUser represents user in struct template data.
+type tplUser struct {
+	ID   int
+	Name string
+	Tags []*tplTag
+}
+
+// tplTag represents user tag in struct template data.
+type tplTag struct {
+	Name string
+}
+
+// tplData represents struct template data.
+type tplData struct {
+	Token string
+	User  *tplUser
+}
+
+func Test_Open_structData(t *testing.T) {
+	// --- Given ---
+	data := &tplData{
+		Token: "tok",
+		User: &tplUser{
+			ID:   1,
+			Name: "Bob",
+			Tags: []*tplTag{{Name: "a"}, {Name: "b"}},
+		},
+	}
+
+	// --- When ---
+	gld := NewRequest(Open(t, "testdata/request_struct.tpl.yaml", data))
+
+	// --- Then ---
+	assert.Exactly(t, "/users/1", gld.Path)
+	assert.Exactly(t, "Authorization: Bearer tok", gld.Headers[0])
+	assert.JSONEq(t, `{"name": "Bob", "tags": ["a", "b"]}`, gld.Body)
+}
+
+func Test_Open_structData_unknownFields(t *testing.T) {
+	// --- Given ---
+	mck := &TMock{}
+	mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+		exp := "unknown template fields: " +
+			"testdata/request_struct_stale.tpl.yaml: " +
+			".User.Id, $.Tok, .Nmae, .Label"
+		return errors.Is(err, ErrUnknownField) && err.Error() == exp
+	}))
+
+	// --- When ---
+	Open(mck, "testdata/request_struct_stale.tpl.yaml", tplData{})
+
+	// --- Then ---
+	mck.AssertExpectations(t)
+}
+
+func Test_checkFields(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		tpl string
+		exp string
+	}{
+		{"field", `{{ .Token }}`, ""},
+		{"nested field", `{{ .User.Name }}`, ""},
+		{"unknown field", `{{ .Tok }}`, ".Tok"},
+		{"unknown nested field", `{{ .User.Nmae }}`, ".User.Nmae"},
+		{"unexported field", `{{ .token }}`, ".token"},
+		{"root variable", `{{ $.User.ID }}`, ""},
+		{"with", `{{ with .User }}{{ .Name }}{{ .Bad }}{{ end }}`, ".Bad"},
+		{"with else", `{{ with .User }}{{ else }}{{ .Bad }}{{ end }}`, ".Bad"},
+		{"range", `{{ range .User.Tags }}{{ .Name }}{{ .Bad }}{{ end }}`, ".Bad"},
+		{"if", `{{ if .Bad }}{{ .Token }}{{ end }}`, ".Bad"},
+		{"function arg", `{{ default "x" .Bad }}`, ".Bad"},
+		{"pipeline", `{{ .Bad | json }}`, ".Bad"},
+		{"unknown dot type", `{{ with json .User }}{{ .Anything }}{{ end }}`, ""},
+		{"method", `{{ .Upper }}`, ""},
+		{"duplicates", `{{ .Bad }}{{ .Bad }}`, ".Bad"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- Given ---
+			tpl := template.Must(template.New("golden.yaml").Funcs(FuncMap()).Parse(tc.tpl))
+
+			// --- When ---
+			err := checkFields(tpl, tplMethods{})
+
+			// --- Then ---
+			if tc.exp == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrUnknownField))
+			assert.Exactly(t, "unknown template fields: golden.yaml: "+tc.exp, err.Error())
+		})
+	}
+}
+
+func Test_checkFields_notStruct(t *testing.T) {
+	// --- Given ---
+	tpl := template.Must(template.New("golden.yaml").Parse(`{{ .Bad }}`))
+
+	// --- Then ---
+	assert.NoError(t, checkFields(tpl, nil))
+	assert.NoError(t, checkFields(tpl, Map{}))
+}
+
+// tplMethods represents struct template data with a method.
+type tplMethods struct {
+	tplData
+
+	token string
+}
+
+// Upper returns upper cased token.
+func (tm *tplMethods) Upper() string { return strings.ToUpper(tm.Token) }
+
+func Test_Open_includeFunc_cycle(t *testing.T) {
+	tt := []struct {
+		testN string
+
+		files map[string]string
+	}{
+		{"self", map[string]string{
+			"file0.yaml": `{{ include "file0.yaml" . }}`,
+		}},
+		{"indirect", map[string]string{
+			"file0.yaml": `{{ include "file1.yaml" . }}`,
+			"file1.yaml": `{{ include "file0.yaml" . }}`,
+		}},
+		{"mixed with tag", map[string]string{
+			"file0.yaml": "meta: !include file1.yaml\n",
+			"file1.yaml": `{{ include "file0.yaml" . }}`,
+		}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testN, func(t *testing.T) {
+			// --- Given ---
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				pth := filepath.Join(dir, name)
+				require.NoError(t, ioutil.WriteFile(pth, []byte(content), 0600))
+			}
+
+			mck := &TMock{}
+			mck.On("Fatal", mock.MatchedBy(func(err error) bool {
+				return errors.Is(err, ErrIncludeCycle)
+			}))
+
+			// --- When ---
+			Open(mck, filepath.Join(dir, "file0.yaml"), Map{})
+
+			// --- Then ---
+			mck.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_Open_includeFunc_sameFileTwice(t *testing.T) {
+	// --- Given ---
+	dir := t.TempDir()
+	src := "bodyType: text\nbody: {{ include \"val.txt\" . }} {{ include \"val.txt\" . }}\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.yaml"), []byte(src), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "val.txt"), []byte("{{ .val }}"), 0600))
+
+	// --- When ---
+	gld := New(Open(t, filepath.Join(dir, "file.yaml"), Map{"val": "val"}))
+
+	// --- Then ---
+	assert.Exactly(t, "val val", gld.Body)
+}
@@ -0,0 +1,204 @@
+package golden
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ErrUnknownField represents an error when golden file template references
+// fields which do not exist on the template data struct.
+var ErrUnknownField = errors.New("unknown template fields")
+
+// checkFields checks every field referenced in the template exists on
+// the data struct. It does nothing when data is not a struct or a pointer
+// to a struct.
+func checkFields(tpl *template.Template, data interface{}) error {
+	typ := reflect.TypeOf(data)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct || tpl.Tree == nil {
+		return nil
+	}
+
+	fc := &fieldChecker{root: typ}
+	fc.walk(tpl.Tree.Root, typ)
+	if len(fc.unknown) > 0 {
+		return fmt.Errorf(
+			"%w: %s: %s",
+			ErrUnknownField,
+			tpl.Name(),
+			strings.Join(fc.unknown, ", "),
+		)
+	}
+	return nil
+}
+
+// fieldChecker walks template parse tree looking for references to fields
+// missing from the template data types. The nil type means the type of
+// the value is not known, in which case the references are not checked.
+type fieldChecker struct {
+	root    reflect.Type // Template data type.
+	unknown []string     // Unknown field references.
+}
+
+// walk walks node with dot of type typ.
+func (fc *fieldChecker) walk(node parse.Node, typ reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, nn := range n.Nodes {
+			fc.walk(nn, typ)
+		}
+
+	case *parse.ActionNode:
+		fc.pipe(n.Pipe, typ)
+
+	case *parse.IfNode:
+		fc.pipe(n.Pipe, typ)
+		fc.walk(n.List, typ)
+		fc.walk(n.ElseList, typ)
+
+	case *parse.WithNode:
+		fc.walk(n.List, fc.pipe(n.Pipe, typ))
+		fc.walk(n.ElseList, typ)
+
+	case *parse.RangeNode:
+		fc.walk(n.List, elemType(fc.pipe(n.Pipe, typ)))
+		fc.walk(n.ElseList, typ)
+
+	case *parse.TemplateNode:
+		fc.pipe(n.Pipe, typ)
+	}
+}
+
+// pipe checks field references in the pipeline and returns the type of
+// the pipeline value or nil if it's not known.
+func (fc *fieldChecker) pipe(pipe *parse.PipeNode, typ reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+
+	var last reflect.Type
+	for _, cmd := range pipe.Cmds {
+		last = nil
+		for _, arg := range cmd.Args {
+			last = fc.arg(arg, typ)
+		}
+		if len(cmd.Args) != 1 {
+			last = nil
+		}
+	}
+	return last
+}
+
+// arg checks field references in the command argument and returns
+// the type of its value or nil if it's not known.
+func (fc *fieldChecker) arg(arg parse.Node, typ reflect.Type) reflect.Type {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return typ
+
+	case *parse.FieldNode:
+		return fc.field(typ, "", n.Ident)
+
+	case *parse.VariableNode:
+		if n.Ident[0] != "$" {
+			return nil
+		}
+		return fc.field(fc.root, "$", n.Ident[1:])
+
+	case *parse.ChainNode:
+		switch nn := n.Node.(type) {
+		case *parse.DotNode:
+			return fc.field(typ, "", n.Field)
+		case *parse.FieldNode:
+			return fc.field(typ, "", append(append([]string{}, nn.Ident...), n.Field...))
+		case *parse.PipeNode:
+			fc.pipe(nn, typ)
+		}
+		return nil
+
+	case *parse.PipeNode:
+		return fc.pipe(n, typ)
+	}
+	return nil
+}
+
+// field resolves chain of field names starting at typ. It records unknown
+// fields and returns the type of the last field or nil if it's not known.
+func (fc *fieldChecker) field(typ reflect.Type, prefix string, idents []string) reflect.Type {
+	ref := prefix
+	for _, ident := range idents {
+		ref += "." + ident
+		if typ == nil {
+			return nil
+		}
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if mth, ok := reflect.PtrTo(typ).MethodByName(ident); ok {
+			if mth.Type.NumOut() == 0 {
+				return nil
+			}
+			typ = mth.Type.Out(0)
+			continue
+		}
+
+		switch typ.Kind() {
+		case reflect.Struct:
+			fld, ok := typ.FieldByName(ident)
+			if !ok || fld.PkgPath != "" {
+				fc.add(ref)
+				return nil
+			}
+			typ = fld.Type
+
+		case reflect.Map:
+			typ = typ.Elem()
+
+		default:
+			return nil
+		}
+
+		if typ.Kind() == reflect.Interface {
+			return nil
+		}
+	}
+	return typ
+}
+
+// add adds unknown field reference to the list.
+func (fc *fieldChecker) add(ref string) {
+	for _, u := range fc.unknown {
+		if u == ref {
+			return
+		}
+	}
+	fc.unknown = append(fc.unknown, ref)
+}
+
+// elemType returns type of elements ranged over in the value of type typ
+// or nil if it's not known.
+func elemType(typ reflect.Type) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		if typ.Elem().Kind() == reflect.Interface {
+			return nil
+		}
+		return typ.Elem()
+	}
+	return nil
+}